@@ -0,0 +1,295 @@
+package confetti
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Shell is a shell for which WriteCompletion can generate a completion script.
+type Shell string
+
+const (
+	// ShellBash generates a script to be sourced by bash, like from "/etc/bash_completion.d".
+	ShellBash Shell = "bash"
+	// ShellZsh generates a completion function to be placed in a directory of the $fpath, named "_<program>".
+	ShellZsh Shell = "zsh"
+	// ShellFish generates a script to be placed in "~/.config/fish/completions", named "<program>.fish".
+	ShellFish Shell = "fish"
+)
+
+const (
+	// pathKindFile is the PathKind of fields that hold a path to a file.
+	pathKindFile = "file"
+	// pathKindDir is the PathKind of fields that hold a path to a directory.
+	pathKindDir = "dir"
+)
+
+// helpFlagDoc is the usage info of the help flags, as shown by completion scripts.
+const helpFlagDoc = "Show the help documentation."
+
+// shellUnsafeChars matches the characters that cannot be a part of a shell function name.
+var shellUnsafeChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// completionFlag is a flag, as seen by the completion scripts.
+type completionFlag struct {
+	// name is the name of the flag, without the dash.
+	name string
+	// doc is the description of the flag.
+	doc string
+	// takesValue is false for flags that are given without a value, like "-help".
+	takesValue bool
+	// values are the possible values of the flag, if they are known.
+	values []string
+	// pathKind is the PathKind of the flag's field.
+	pathKind string
+	// message describes the value of the flag, like "int" or "duration".
+	message string
+}
+
+// WriteCompletion writes the completion script of the given shell for the flags of the target.
+//
+// The Title option is used as the name of the program to be completed, so it should be the name of the executable.
+// Values of fields with a oneof tag are completed from the allowed values, and values of fields tagged as
+// `path:"file"` or `path:"dir"` are completed as file paths. Positional arguments are completed as file paths
+// if any positional field is tagged as a path.
+func WriteCompletion(output io.Writer, target interface{}, opts LoaderOptions, shell Shell) error {
+	fields, err := Describe(target, opts)
+	if err != nil {
+		return err
+	}
+
+	// Filling out missing option values.
+	opts.complete()
+
+	flags := newCompletionFlags(&opts, fields)
+	posPathKind := positionalPathKind(fields)
+
+	switch shell {
+	case ShellBash:
+		writeBashCompletion(output, opts.Title, flags, posPathKind)
+	case ShellZsh:
+		writeZshCompletion(output, opts.Title, flags, posPathKind)
+	case ShellFish:
+		writeFishCompletion(output, opts.Title, flags, posPathKind)
+	default:
+		return fmt.Errorf("unknown shell: %s", shell)
+	}
+	return nil
+}
+
+// newCompletionFlags creates the completionFlags of the fields, along with the help and version flags.
+func newCompletionFlags(opts *LoaderOptions, fields []FieldInfo) []completionFlag {
+	flags := []completionFlag{{name: "h", doc: helpFlagDoc}, {name: "help", doc: helpFlagDoc}}
+	if opts.Version != "" {
+		flags = append(flags, completionFlag{name: versionFlagName, doc: versionFlagDoc})
+	}
+	if opts.PrintConfigFlag {
+		flags = append(flags, completionFlag{name: printConfigFlagName, doc: printConfigFlagDoc})
+	}
+
+	for _, field := range fields {
+		if field.FlagName == "" {
+			continue
+		}
+
+		flg := completionFlag{
+			name:       field.FlagName,
+			doc:        field.Doc,
+			takesValue: true,
+			values:     field.OneOf,
+			pathKind:   field.PathKind,
+			message:    valueOr(field.TypeName, "value"),
+		}
+		// Boolean flags need an explicit value, as in -verbose=true.
+		if flg.values == nil && field.Type != nil && field.Type.Kind() == reflect.Bool {
+			flg.values = []string{"true", "false"}
+		}
+		flags = append(flags, flg)
+	}
+
+	return flags
+}
+
+// positionalPathKind provides the PathKind that the positional arguments are completed with.
+// Files win over directories, as file completion also lists directories.
+func positionalPathKind(fields []FieldInfo) string {
+	pathKind := ""
+	for _, field := range fields {
+		if field.Position == "" || field.PathKind == "" {
+			continue
+		}
+		if field.PathKind == pathKindFile {
+			return pathKindFile
+		}
+		pathKind = field.PathKind
+	}
+	return pathKind
+}
+
+// writeBashCompletion writes the bash completion script.
+func writeBashCompletion(output io.Writer, name string, flags []completionFlag, posPathKind string) {
+	funcName := "_" + shellUnsafeChars.ReplaceAllString(name, "_") + "_completion"
+
+	_, _ = fmt.Fprintf(output, "# bash completion for %s, generated by confetti.\n", name)
+	_, _ = fmt.Fprintf(output, "%s() {\n", funcName)
+	_, _ = fmt.Fprintln(output, `    local cur="${COMP_WORDS[COMP_CWORD]}"`)
+	_, _ = fmt.Fprintln(output, `    local prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	_, _ = fmt.Fprintln(output)
+
+	// Completing the value of the previous flag.
+	_, _ = fmt.Fprintln(output, `    case "$prev" in`)
+	for _, flg := range flags {
+		if !flg.takesValue {
+			continue
+		}
+		_, _ = fmt.Fprintf(output, "        -%s|--%s)\n", flg.name, flg.name)
+		if line := bashValueCompletion(flg.values, flg.pathKind); line != "" {
+			_, _ = fmt.Fprintf(output, "            %s\n", line)
+		}
+		_, _ = fmt.Fprintln(output, "            return 0")
+		_, _ = fmt.Fprintln(output, "            ;;")
+	}
+	_, _ = fmt.Fprintln(output, "    esac")
+	_, _ = fmt.Fprintln(output)
+
+	// Completing the flag names.
+	names := make([]string, 0, len(flags))
+	for _, flg := range flags {
+		names = append(names, "-"+flg.name)
+	}
+	_, _ = fmt.Fprintln(output, `    if [[ "$cur" == -* ]]; then`)
+	_, _ = fmt.Fprintf(output, "        %s\n", bashValueCompletion(names, ""))
+	_, _ = fmt.Fprintln(output, "        return 0")
+	_, _ = fmt.Fprintln(output, "    fi")
+
+	// Completing the positional arguments.
+	if line := bashValueCompletion(nil, posPathKind); line != "" {
+		_, _ = fmt.Fprintln(output)
+		_, _ = fmt.Fprintf(output, "    %s\n", line)
+	}
+
+	_, _ = fmt.Fprintln(output, "}")
+	_, _ = fmt.Fprintln(output)
+	_, _ = fmt.Fprintf(output, "complete -F %s %s\n", funcName, name)
+}
+
+// bashValueCompletion creates the bash statement that completes the current word with the values or paths.
+// It returns an empty string if there is nothing to complete.
+func bashValueCompletion(values []string, pathKind string) string {
+	switch {
+	case len(values) > 0:
+		return fmt.Sprintf(`COMPREPLY=($(compgen -W %s -- "$cur"))`, bashQuote(strings.Join(values, " ")))
+	case pathKind == pathKindFile:
+		return `compopt -o filenames 2>/dev/null; COMPREPLY=($(compgen -f -- "$cur"))`
+	case pathKind == pathKindDir:
+		return `compopt -o filenames 2>/dev/null; COMPREPLY=($(compgen -d -- "$cur"))`
+	default:
+		return ""
+	}
+}
+
+// writeZshCompletion writes the zsh completion function.
+func writeZshCompletion(output io.Writer, name string, flags []completionFlag, posPathKind string) {
+	specs := make([]string, 0, len(flags)+1)
+	for _, flg := range flags {
+		spec := "-" + flg.name
+		if flg.doc != "" {
+			spec += "[" + zshEscape(flg.doc, "[]") + "]"
+		}
+		if flg.takesValue {
+			spec += fmt.Sprintf(":%s:%s", zshEscape(flg.message, ":"), zshValueAction(flg.values, flg.pathKind))
+		}
+		specs = append(specs, spec)
+	}
+	if posPathKind != "" {
+		specs = append(specs, "*:"+posPathKind+":"+zshValueAction(nil, posPathKind))
+	}
+
+	_, _ = fmt.Fprintf(output, "#compdef %s\n\n", name)
+	_, _ = fmt.Fprintf(output, "# zsh completion for %s, generated by confetti.\n", name)
+	_, _ = fmt.Fprint(output, "_arguments")
+	for _, spec := range specs {
+		_, _ = fmt.Fprintf(output, " \\\n  '%s'", strings.ReplaceAll(spec, "'", `'\''`))
+	}
+	_, _ = fmt.Fprintln(output)
+}
+
+// zshValueAction creates the action of an _arguments spec, which completes the values or paths.
+func zshValueAction(values []string, pathKind string) string {
+	switch {
+	case len(values) > 0:
+		escaped := make([]string, 0, len(values))
+		for _, value := range values {
+			escaped = append(escaped, zshEscape(value, `() `))
+		}
+		return "(" + strings.Join(escaped, " ") + ")"
+	case pathKind == pathKindFile:
+		return "_files"
+	case pathKind == pathKindDir:
+		return "_files -/"
+	default:
+		// A single space shows the message without completing anything.
+		return " "
+	}
+}
+
+// writeFishCompletion writes the fish completion script.
+func writeFishCompletion(output io.Writer, name string, flags []completionFlag, posPathKind string) {
+	_, _ = fmt.Fprintf(output, "# fish completion for %s, generated by confetti.\n", name)
+
+	// Positional arguments are not completed as files unless they are paths.
+	command := "complete -c " + fishQuote(name)
+	switch posPathKind {
+	case "":
+		_, _ = fmt.Fprintf(output, "%s -f\n", command)
+	case pathKindDir:
+		_, _ = fmt.Fprintf(output, "%s -f -a '(__fish_complete_directories)'\n", command)
+	}
+
+	for _, flg := range flags {
+		line := fmt.Sprintf("%s -o %s", command, fishQuote(flg.name))
+		if flg.doc != "" {
+			line += " -d " + fishQuote(flg.doc)
+		}
+
+		switch {
+		case !flg.takesValue:
+		case len(flg.values) > 0:
+			line += " -x -a " + fishQuote(strings.Join(flg.values, " "))
+		case flg.pathKind == pathKindFile:
+			line += " -r -F"
+		case flg.pathKind == pathKindDir:
+			line += " -x -a '(__fish_complete_directories)'"
+		default:
+			line += " -x"
+		}
+		_, _ = fmt.Fprintln(output, line)
+	}
+}
+
+// bashQuote quotes the value as a double quoted bash string.
+func bashQuote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// fishQuote quotes the value as a single quoted fish string.
+func fishQuote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + replacer.Replace(value) + "'"
+}
+
+// zshEscape escapes the given characters, and the backslash, with a backslash, as _arguments specs require.
+func zshEscape(value string, chars string) string {
+	var builder strings.Builder
+	for _, char := range value {
+		if char == '\\' || strings.ContainsRune(chars, char) {
+			builder.WriteRune('\\')
+		}
+		builder.WriteRune(char)
+	}
+	return builder.String()
+}
@@ -0,0 +1,124 @@
+package confetti
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// dummyCompletionTarget is the target used by the completion tests.
+type dummyCompletionTarget struct {
+	LogLevel string `arg:"log-level,Log level" oneof:"debug info"`
+	Config   string `arg:"config,Config file" path:"file"`
+	DataDir  string `arg:"data-dir,Data directory" path:"dir"`
+	Port     int    `arg:"port,HTTP port [main]"`
+	Verbose  bool   `arg:"verbose"`
+	Input    string `pos:"0" path:"file"`
+	NoFlag   string `env:"NO_FLAG"`
+}
+
+// TestWriteCompletion_Bash tests if the bash script completes the flags, their values and the file paths.
+func TestWriteCompletion_Bash(t *testing.T) {
+	output := &bytes.Buffer{}
+	opts := LoaderOptions{Title: "my-app"}
+	if err := WriteCompletion(output, &dummyCompletionTarget{}, opts, ShellBash); err != nil {
+		t.Errorf("Expected WriteCompletion error: nil, got: %+v", err)
+		return
+	}
+
+	expectedLines := []string{
+		`_my_app_completion() {`,
+		`        -log-level|--log-level)`,
+		`            COMPREPLY=($(compgen -W "debug info" -- "$cur"))`,
+		`            compopt -o filenames 2>/dev/null; COMPREPLY=($(compgen -f -- "$cur"))`,
+		`            compopt -o filenames 2>/dev/null; COMPREPLY=($(compgen -d -- "$cur"))`,
+		`            COMPREPLY=($(compgen -W "true false" -- "$cur"))`,
+		`        COMPREPLY=($(compgen -W "-h -help -log-level -config -data-dir -port -verbose" -- "$cur"))`,
+		`    compopt -o filenames 2>/dev/null; COMPREPLY=($(compgen -f -- "$cur"))`,
+		`complete -F _my_app_completion my-app`,
+	}
+	assertLines(t, output.String(), expectedLines)
+
+	// The script should at least be valid bash, if bash is available.
+	if _, err := exec.LookPath("bash"); err == nil {
+		cmd := exec.Command("bash", "-n")
+		cmd.Stdin = output
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("Expected the bash script to be valid, got: %s", out)
+		}
+	}
+}
+
+// TestWriteCompletion_Zsh tests if the zsh function has an _arguments spec for every flag.
+func TestWriteCompletion_Zsh(t *testing.T) {
+	output := &bytes.Buffer{}
+	opts := LoaderOptions{Title: "my-app", Version: "v1.0.0"}
+	if err := WriteCompletion(output, &dummyCompletionTarget{}, opts, ShellZsh); err != nil {
+		t.Errorf("Expected WriteCompletion error: nil, got: %+v", err)
+		return
+	}
+
+	expected := `#compdef my-app
+
+# zsh completion for my-app, generated by confetti.
+_arguments \
+  '-h[Show the help documentation.]' \
+  '-help[Show the help documentation.]' \
+  '-version[Print the version and exit.]' \
+  '-log-level[Log level]:string:(debug info)' \
+  '-config[Config file]:string:_files' \
+  '-data-dir[Data directory]:string:_files -/' \
+  '-port[HTTP port \[main\]]:int: ' \
+  '-verbose:bool:(true false)' \
+  '*:file:_files'
+`
+
+	if output.String() != expected {
+		t.Errorf("Expected zsh script:\n%s\nbut got:\n%s", expected, output.String())
+	}
+}
+
+// TestWriteCompletion_Fish tests if the fish script completes the flags, their values and the file paths.
+func TestWriteCompletion_Fish(t *testing.T) {
+	output := &bytes.Buffer{}
+	opts := LoaderOptions{Title: "my-app"}
+	if err := WriteCompletion(output, &dummyCompletionTarget{}, opts, ShellFish); err != nil {
+		t.Errorf("Expected WriteCompletion error: nil, got: %+v", err)
+		return
+	}
+
+	expected := `# fish completion for my-app, generated by confetti.
+complete -c 'my-app' -o 'h' -d 'Show the help documentation.'
+complete -c 'my-app' -o 'help' -d 'Show the help documentation.'
+complete -c 'my-app' -o 'log-level' -d 'Log level' -x -a 'debug info'
+complete -c 'my-app' -o 'config' -d 'Config file' -r -F
+complete -c 'my-app' -o 'data-dir' -d 'Data directory' -x -a '(__fish_complete_directories)'
+complete -c 'my-app' -o 'port' -d 'HTTP port [main]' -x
+complete -c 'my-app' -o 'verbose' -x -a 'true false'
+`
+
+	if output.String() != expected {
+		t.Errorf("Expected fish script:\n%s\nbut got:\n%s", expected, output.String())
+	}
+}
+
+// TestWriteCompletion_UnknownShell tests if WriteCompletion returns an error for an unknown shell.
+func TestWriteCompletion_UnknownShell(t *testing.T) {
+	err := WriteCompletion(&bytes.Buffer{}, &dummyCompletionTarget{}, LoaderOptions{}, "powershell")
+	if err == nil || !strings.Contains(err.Error(), "unknown shell") {
+		t.Errorf("Expected unknown shell error, got: %+v", err)
+	}
+}
+
+// assertLines checks if all the expected lines are present in the output.
+func assertLines(t *testing.T, output string, expectedLines []string) {
+	t.Helper()
+
+	lines := strings.Split(output, "\n")
+	for _, expected := range expectedLines {
+		if !containsString(lines, expected) {
+			t.Errorf("Expected line:\n%s\nin output:\n%s", expected, output)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Package confettipflag binds confetti targets to spf13/pflag FlagSets.
+//
+// It makes confetti usable with CLIs built on cobra, whose commands expose their flags as a *pflag.FlagSet:
+//
+//	cmd.Flags() is passed to Bind while building the command,
+//	and to Load inside the Run/RunE function, after cobra has parsed the flags.
+//
+// The precedence of values stays the same as confetti's, that is, flags override
+// environment variables, which override the default values.
+package confettipflag
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/shivanshkc/confetti/v2"
+	"github.com/spf13/pflag"
+)
+
+// ShortTagName is the name of the tag that holds the single letter shorthand of a flag.
+// Example: `arg:"port,HTTP server port" short:"p"` allows both --port and -p.
+const ShortTagName = "short"
+
+// These are the flag annotations that make cobra complete the value of a flag as a file or directory path.
+// They are the same as the ones set by cobra's MarkFlagFilename and MarkFlagDirname.
+const (
+	cobraFilenameAnnotation = "cobra_annotation_bash_completion_filename_extensions"
+	cobraDirnameAnnotation  = "cobra_annotation_bash_completion_subdirs_in_dir"
+)
+
+// Bind registers the fields of the target into the FlagSet as GNU-style flags.
+//
+// The flag names, docs, defaults and environment variables are read from the struct tags
+// in the same way as confetti does. Flags that already exist in the FlagSet are left untouched.
+func Bind(flagSet *pflag.FlagSet, target interface{}, opts confetti.LoaderOptions) error {
+	if flagSet == nil {
+		return errors.New("flagSet must not be nil")
+	}
+
+	infos, err := confetti.Describe(target, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		if info.FlagName == "" || flagSet.Lookup(info.FlagName) != nil {
+			continue
+		}
+
+		shorthand := info.Tag.Get(ShortTagName)
+		if len(shorthand) > 1 {
+			return fmt.Errorf(`shorthand of field "%s" must be a single letter, got: "%s"`, info.Path, shorthand)
+		}
+
+		flg := flagSet.VarPF(&flagValue{typeName: info.TypeName}, info.FlagName, shorthand, formatUsage(info))
+		flg.DefValue = info.Default
+		// The default values of secret fields are never shown in the help output.
+		if info.Secret && info.Default != "" {
+			flg.DefValue = confetti.RedactedValue
+		}
+		// Boolean flags should work without an explicit value, as in --verbose.
+		if info.Type.Kind() == reflect.Bool {
+			flg.NoOptDefVal = "true"
+		}
+
+		// Fields tagged as paths get completed as such by cobra.
+		switch info.PathKind {
+		case "file":
+			_ = flagSet.SetAnnotation(info.FlagName, cobraFilenameAnnotation, []string{})
+		case "dir":
+			_ = flagSet.SetAnnotation(info.FlagName, cobraDirnameAnnotation, []string{})
+		}
+	}
+
+	return nil
+}
+
+// Load loads the configs into the target. It should be called after the FlagSet has been parsed.
+func Load(flagSet *pflag.FlagSet, target interface{}, opts confetti.LoaderOptions) error {
+	if flagSet == nil {
+		return errors.New("flagSet must not be nil")
+	}
+	if !flagSet.Parsed() {
+		return errors.New("the flagSet must be parsed before calling Load")
+	}
+
+	opts.FlagSource = &flagSource{flagSet: flagSet}
+	return confetti.NewLoader(opts).Load(target)
+}
+
+// flagSource implements confetti.FlagSource using a pflag.FlagSet.
+type flagSource struct {
+	flagSet *pflag.FlagSet
+}
+
+func (f *flagSource) LookupFlag(flagName string) (flagValue string, exists bool) {
+	flg := f.flagSet.Lookup(flagName)
+	if flg == nil || !flg.Changed {
+		return "", false
+	}
+	return flg.Value.String(), true
+}
+
+// Args provides the positional arguments, which allows binding them with the pos tag.
+func (f *flagSource) Args() []string {
+	return f.flagSet.Args()
+}
+
+// flagValue implements pflag.Value. It keeps the raw string so confetti can convert it later.
+type flagValue struct {
+	// value is the raw value of the flag.
+	value string
+	// typeName is shown in the help output.
+	typeName string
+}
+
+func (f *flagValue) String() string { return f.value }
+
+func (f *flagValue) Set(s string) error {
+	f.value = s
+	return nil
+}
+
+func (f *flagValue) Type() string { return f.typeName }
+
+// formatUsage creates the usage info of a flag using its doc and environment variable.
+func formatUsage(info confetti.FieldInfo) string {
+	if info.Env == "" {
+		return info.Doc
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s (env %s)", info.Doc, info.Env))
+}
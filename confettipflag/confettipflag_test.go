@@ -0,0 +1,145 @@
+package confettipflag
+
+import (
+	"os"
+	"testing"
+
+	"github.com/shivanshkc/confetti/v2"
+	"github.com/spf13/pflag"
+)
+
+// dummyTarget is the target used by all tests of this package.
+type dummyTarget struct {
+	HTTP struct {
+		Port int `def:"8080" env:"CONFETTI_PFLAG_PORT" arg:"http-port,HTTP server port" short:"p"`
+	}
+	Verbose bool     `def:"false" arg:"verbose,Enable verbose logs" short:"v"`
+	Origins []string `def:"[]" env:"CONFETTI_PFLAG_ORIGINS" arg:"origins,Trusted origins"`
+	Name    string   `def:"confetti" env:"CONFETTI_PFLAG_NAME" arg:"name"`
+}
+
+// TestBind tests if Bind registers all flags with their shorthands and types.
+func TestBind(t *testing.T) {
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	if err := Bind(flagSet, &dummyTarget{}, confetti.LoaderOptions{}); err != nil {
+		t.Errorf("Expected Bind error: nil, got: %+v", err)
+		return
+	}
+
+	expected := map[string][2]string{
+		"http-port": {"p", "int"},
+		"verbose":   {"v", "bool"},
+		"origins":   {"", "list of string"},
+		"name":      {"", "string"},
+	}
+
+	for name, details := range expected {
+		flg := flagSet.Lookup(name)
+		if flg == nil {
+			t.Errorf("Expected flag %s to be registered, but it is not.", name)
+			continue
+		}
+		if flg.Shorthand != details[0] {
+			t.Errorf("Expected flag %s shorthand to be: %s, got: %s", name, details[0], flg.Shorthand)
+		}
+		if flg.Value.Type() != details[1] {
+			t.Errorf("Expected flag %s type to be: %s, got: %s", name, details[1], flg.Value.Type())
+		}
+	}
+}
+
+// TestBind_BadShorthand tests if Bind gives an error for shorthands longer than a letter.
+func TestBind_BadShorthand(t *testing.T) {
+	target := struct {
+		Port int `arg:"port" short:"pp"`
+	}{}
+
+	if err := Bind(pflag.NewFlagSet("test", pflag.ContinueOnError), &target, confetti.LoaderOptions{}); err == nil {
+		t.Errorf("Expected error from Bind, but didn't get any.")
+	}
+}
+
+// TestBind_PathAnnotations tests if Bind annotates the path flags for cobra's file completion.
+func TestBind_PathAnnotations(t *testing.T) {
+	target := struct {
+		Config  string `arg:"config" path:"file"`
+		DataDir string `arg:"data-dir" path:"dir"`
+	}{}
+
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	if err := Bind(flagSet, &target, confetti.LoaderOptions{}); err != nil {
+		t.Errorf("Expected Bind error: nil, got: %+v", err)
+		return
+	}
+
+	if _, exists := flagSet.Lookup("config").Annotations[cobraFilenameAnnotation]; !exists {
+		t.Errorf("Expected flag config to have the annotation: %s", cobraFilenameAnnotation)
+	}
+	if _, exists := flagSet.Lookup("data-dir").Annotations[cobraDirnameAnnotation]; !exists {
+		t.Errorf("Expected flag data-dir to have the annotation: %s", cobraDirnameAnnotation)
+	}
+}
+
+// TestBind_Secret tests if Bind hides the default values of secret fields from the help output.
+func TestBind_Secret(t *testing.T) {
+	target := struct {
+		Password string `def:"hunter2" arg:"password" secret:"true"`
+	}{}
+
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	if err := Bind(flagSet, &target, confetti.LoaderOptions{}); err != nil {
+		t.Errorf("Expected Bind error: nil, got: %+v", err)
+		return
+	}
+
+	if defValue := flagSet.Lookup("password").DefValue; defValue != confetti.RedactedValue {
+		t.Errorf("Expected the default value to be redacted, got: %s", defValue)
+	}
+}
+
+// TestLoad tests if Load resolves values with the correct precedence after parsing.
+func TestLoad(t *testing.T) {
+	_ = os.Setenv("CONFETTI_PFLAG_PORT", "9090")
+	_ = os.Setenv("CONFETTI_PFLAG_NAME", "from-env")
+	defer func() {
+		_ = os.Unsetenv("CONFETTI_PFLAG_PORT")
+		_ = os.Unsetenv("CONFETTI_PFLAG_NAME")
+	}()
+
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	target := &dummyTarget{}
+
+	if err := Bind(flagSet, target, confetti.LoaderOptions{}); err != nil {
+		t.Errorf("Expected Bind error: nil, got: %+v", err)
+		return
+	}
+
+	// Load must not work before parsing.
+	if err := Load(flagSet, target, confetti.LoaderOptions{}); err == nil {
+		t.Errorf("Expected Load error before parsing, but didn't get any.")
+		return
+	}
+
+	if err := flagSet.Parse([]string{"-v", "--origins", `["a.com"]`, "--name=from-flag"}); err != nil {
+		t.Errorf("Expected Parse error: nil, got: %+v", err)
+		return
+	}
+
+	if err := Load(flagSet, target, confetti.LoaderOptions{}); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+
+	if target.HTTP.Port != 9090 {
+		t.Errorf("Expected HTTP.Port to be: 9090, got: %d", target.HTTP.Port)
+	}
+	if !target.Verbose {
+		t.Errorf("Expected Verbose to be: true, got: false")
+	}
+	if len(target.Origins) != 1 || target.Origins[0] != "a.com" {
+		t.Errorf("Expected Origins to be: [a.com], got: %+v", target.Origins)
+	}
+	if target.Name != "from-flag" {
+		t.Errorf("Expected Name to be: from-flag, got: %s", target.Name)
+	}
+}
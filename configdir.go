@@ -0,0 +1,112 @@
+package confetti
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configDirDataLink is the symlink that Kubernetes swaps atomically when a mounted ConfigMap or Secret is updated.
+const configDirDataLink = "..data"
+
+// dirSource provides the values of a directory that holds a file per key, like a mounted ConfigMap.
+//
+// The directory is read once, on the first lookup. When the directory has the "..data" symlink, it is
+// resolved at that time, and all files are read from its target. So, a single dirSource sees a consistent
+// version of the directory, even if Kubernetes swaps it in the meantime.
+type dirSource struct {
+	// dir is the directory to be read.
+	dir string
+	// root is the directory that the files are actually read from, after resolving the "..data" symlink.
+	root string
+	// files maps the relative paths of all files, with "/" separators, to their absolute paths.
+	files map[string]string
+	// foldedFiles is the same as files, but with lower case keys, for the case-insensitive lookups.
+	foldedFiles map[string]string
+}
+
+// newDirSource returns a new dirSource for the given directory.
+func newDirSource(dir string) *dirSource {
+	return &dirSource{dir: dir}
+}
+
+// lookup provides the value of the first key that has a file. The envKey is matched exactly, while the
+// pathKey, which is the nested path of the field like "http/port", is matched regardless of case.
+// The second return param is the key of the file that provided the value.
+func (d *dirSource) lookup(envKey string, pathKey string) (string, string, bool, error) {
+	if d.files == nil {
+		if err := d.read(); err != nil {
+			return "", "", false, err
+		}
+	}
+
+	path, key := d.files[envKey], envKey
+	if path == "" {
+		path, key = d.foldedFiles[strings.ToLower(pathKey)], pathKey
+	}
+	if path == "" {
+		return "", "", false, nil
+	}
+
+	value, err := readValueFile(path)
+	if err != nil {
+		return "", key, false, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return value, key, true, nil
+}
+
+// read lists all files of the directory.
+func (d *dirSource) read() error {
+	d.root = d.dir
+	// The "..data" symlink points to the current version of the directory.
+	if target, err := filepath.EvalSymlinks(filepath.Join(d.dir, configDirDataLink)); err == nil {
+		d.root = target
+	}
+
+	d.files, d.foldedFiles = map[string]string{}, map[string]string{}
+	err := filepath.WalkDir(d.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Hidden entries, like the "..data" symlink and the versioned directories, are not keys.
+		if path != d.root && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		// Symlinks to directories are not followed, but symlinks to files are read like files.
+		if entry.Type()&fs.ModeSymlink != 0 {
+			if info, err := os.Stat(path); err != nil || info.IsDir() {
+				return nil
+			}
+		}
+
+		relative, err := filepath.Rel(d.root, path)
+		if err != nil {
+			return err
+		}
+		relative = filepath.ToSlash(relative)
+		d.files[relative] = path
+		d.foldedFiles[strings.ToLower(relative)] = path
+		return nil
+	})
+	if err != nil {
+		d.files = nil
+		return fmt.Errorf("failed to read config dir: %w", err)
+	}
+	return nil
+}
+
+// configDirPathKey provides the nested path of the field as it is looked up in the config dir.
+// Example: the field HTTP.Port is looked up as "http/port".
+func configDirPathKey(parents []rsf, field rsf) string {
+	return strings.ToLower(strings.ReplaceAll(formatNestedFieldName(parents, field), ".", "/"))
+}
@@ -0,0 +1,115 @@
+package confetti
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// dummyConfigDirTarget is the target used by the config dir tests.
+type dummyConfigDirTarget struct {
+	LogLevel string `def:"info" env:"CONFETTI_DIR_LOG_LEVEL"`
+	HTTP     struct {
+		Port int `def:"8080"`
+	}
+	Name string `def:"confetti" env:"CONFETTI_DIR_NAME"`
+}
+
+// writeConfigDirFiles writes the files into the directory, creating the parent directories as required.
+func writeConfigDirFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatalf("Failed to create the directory: %+v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write the file: %+v", err)
+		}
+	}
+}
+
+// TestImplLoader_Load_ConfigDir tests if the values are read from the files named after the env names or the paths.
+func TestImplLoader_Load_ConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigDirFiles(t, dir, map[string]string{
+		"CONFETTI_DIR_LOG_LEVEL": "debug\n",
+		"http/port":              "9090",
+		"CONFETTI_DIR_NAME":      "from-dir",
+	})
+
+	// The environment variables take precedence over the config dir.
+	_ = os.Setenv("CONFETTI_DIR_NAME", "from-env")
+	defer func() { _ = os.Unsetenv("CONFETTI_DIR_NAME") }()
+
+	target := &dummyConfigDirTarget{}
+	reports, err := New(LoaderOptions{ConfigDir: dir, Args: []string{}}).LoadWithReport(target)
+	if err != nil {
+		t.Errorf("Expected LoadWithReport error: nil, got: %+v", err)
+		return
+	}
+
+	if target.LogLevel != "debug" || target.HTTP.Port != 9090 || target.Name != "from-env" {
+		t.Errorf("Unexpected target: %+v", target)
+	}
+	if reports[1].Source != SourceConfigDir || reports[1].Key != "http/port" {
+		t.Errorf("Unexpected report: %+v", reports[1])
+	}
+}
+
+// TestImplLoader_Load_ConfigDirDataLink tests if the "..data" symlink of Kubernetes is resolved once per Load.
+func TestImplLoader_Load_ConfigDirDataLink(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigDirFiles(t, dir, map[string]string{"..v1/CONFETTI_DIR_LOG_LEVEL": "warn"})
+	if err := os.Symlink("..v1", filepath.Join(dir, configDirDataLink)); err != nil {
+		t.Skipf("Symlinks are not supported: %+v", err)
+	}
+	if err := os.Symlink(filepath.Join(configDirDataLink, "CONFETTI_DIR_LOG_LEVEL"),
+		filepath.Join(dir, "CONFETTI_DIR_LOG_LEVEL")); err != nil {
+		t.Errorf("Failed to create the symlink: %+v", err)
+		return
+	}
+
+	loader := NewLoader(LoaderOptions{ConfigDir: dir, Args: []string{}})
+	target := &dummyConfigDirTarget{}
+	if err := loader.Load(target); err != nil || target.LogLevel != "warn" {
+		t.Errorf("Expected LogLevel: warn, got: %s, err: %+v", target.LogLevel, err)
+		return
+	}
+
+	// A snapshot keeps reading the version that it has resolved, even after the swap.
+	source := newDirSource(dir)
+	if _, _, _, err := source.lookup("CONFETTI_DIR_LOG_LEVEL", ""); err != nil {
+		t.Errorf("Expected lookup error: nil, got: %+v", err)
+		return
+	}
+
+	// Swapping the "..data" symlink, as Kubernetes does on updates.
+	writeConfigDirFiles(t, dir, map[string]string{"..v2/CONFETTI_DIR_LOG_LEVEL": "error"})
+	tempLink := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink("..v2", tempLink); err != nil {
+		t.Errorf("Failed to create the symlink: %+v", err)
+		return
+	}
+	if err := os.Rename(tempLink, filepath.Join(dir, configDirDataLink)); err != nil {
+		t.Errorf("Failed to swap the symlink: %+v", err)
+		return
+	}
+
+	if value, _, _, _ := source.lookup("CONFETTI_DIR_LOG_LEVEL", ""); value != "warn" {
+		t.Errorf("Expected the snapshot value: warn, got: %s", value)
+	}
+
+	// The next Load sees the new version.
+	if err := loader.Load(target); err != nil || target.LogLevel != "error" {
+		t.Errorf("Expected LogLevel: error, got: %s, err: %+v", target.LogLevel, err)
+	}
+}
+
+// TestImplLoader_Load_ConfigDirMissing tests if a missing config dir results in an error.
+func TestImplLoader_Load_ConfigDirMissing(t *testing.T) {
+	opts := LoaderOptions{ConfigDir: filepath.Join(t.TempDir(), "missing"), Args: []string{}}
+	if err := NewLoader(opts).Load(&dummyConfigDirTarget{}); err == nil {
+		t.Errorf("Expected error from Load, but didn't get any.")
+	}
+}
@@ -0,0 +1,205 @@
+package confetti
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DocFormat is the format of the documentation generated by WriteDocs.
+type DocFormat string
+
+const (
+	// DocFormatMarkdown generates Markdown tables, one per section.
+	DocFormatMarkdown DocFormat = "markdown"
+	// DocFormatMan generates the OPTIONS and ENVIRONMENT sections of a troff man page.
+	DocFormatMan DocFormat = "man"
+	// DocFormatText generates plain text, in the same layout as the help documentation.
+	DocFormatText DocFormat = "text"
+	// DocFormatDotEnv generates a commented .env.example file. See WriteDotEnvExample for details.
+	DocFormatDotEnv DocFormat = "dotenv"
+)
+
+// WriteDocs writes the documentation of all fields of the target in the given format.
+//
+// The fields are read using Describe, which reads the struct tags in the same way as the help documentation,
+// so the generated docs never drift from the code.
+func WriteDocs(output io.Writer, target interface{}, opts LoaderOptions, format DocFormat) error {
+	fields, err := Describe(target, opts)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case DocFormatMarkdown:
+		writeMarkdownDocs(output, fields)
+	case DocFormatMan:
+		writeManDocs(output, fields)
+	case DocFormatText:
+		writeFieldTable(output, groupFields(fields))
+	case DocFormatDotEnv:
+		writeDotEnvExample(output, fields)
+	default:
+		return fmt.Errorf("unknown doc format: %s", format)
+	}
+	return nil
+}
+
+// GenerateDocs writes the documentation of the target into the file at the given path.
+// The format is decided by the file name: ".md" for Markdown, ".1" to ".9" for man,
+// names starting with ".env" for dotenv, and text otherwise.
+//
+// It is meant to be used with "go generate", through a small program saved as gen_docs.go
+// in the package of the configs, like the following:
+//
+//	//go:build ignore
+//
+//	package main
+//
+//	import (
+//		"log"
+//
+//		"github.com/shivanshkc/confetti/v2"
+//		"your.module/configs"
+//	)
+//
+//	func main() {
+//		if err := confetti.GenerateDocs("CONFIGS.md", &configs.Configs{}, confetti.LoaderOptions{}); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+//
+// along with a "//go:generate go run gen_docs.go" directive in any other file of the package.
+// See the example of GenerateDocs for a runnable version.
+func GenerateDocs(path string, target interface{}, opts LoaderOptions) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create docs file: %w", err)
+	}
+
+	if err := WriteDocs(file, target, opts, docFormatOf(path)); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close docs file: %w", err)
+	}
+	return nil
+}
+
+// docFormatOf decides the DocFormat using the name of the file.
+func docFormatOf(path string) DocFormat {
+	extension := filepath.Ext(path)
+	switch {
+	case strings.HasPrefix(filepath.Base(path), ".env"):
+		return DocFormatDotEnv
+	case extension == ".md" || extension == ".markdown":
+		return DocFormatMarkdown
+	case len(extension) == 2 && extension[1] >= '1' && extension[1] <= '9':
+		return DocFormatMan
+	default:
+		return DocFormatText
+	}
+}
+
+// writeMarkdownDocs writes the fields as Markdown tables, one per section.
+func writeMarkdownDocs(output io.Writer, fields []FieldInfo) {
+	for ind, section := range groupFields(fields) {
+		if ind > 0 {
+			_, _ = fmt.Fprintln(output)
+		}
+
+		if section[0].Group != "" {
+			_, _ = fmt.Fprintf(output, "### %s\n\n", section[0].Group)
+			if section[0].GroupDoc != "" {
+				_, _ = fmt.Fprintf(output, "%s\n\n", section[0].GroupDoc)
+			}
+		}
+
+		_, _ = fmt.Fprintln(output, "| Flag | Env | Type | Default | Required | Description |")
+		_, _ = fmt.Fprintln(output, "| ---- | --- | ---- | ------- | -------- | ----------- |")
+
+		for _, field := range section {
+			row := formatUsageRow(field)
+			required := "no"
+			if field.Required {
+				required = "yes"
+			}
+
+			cells := []string{
+				markdownCode(row[0]), markdownCode(field.Env), row[1],
+				markdownCode(displayDefault(field)), required, formatDocsDescription(field),
+			}
+			for col := range cells {
+				cells[col] = strings.ReplaceAll(valueOr(cells[col], notProvided), "|", `\|`)
+			}
+			_, _ = fmt.Fprintf(output, "| %s |\n", strings.Join(cells, " | "))
+		}
+	}
+}
+
+// writeManDocs writes the fields as the OPTIONS and ENVIRONMENT sections of a troff man page.
+func writeManDocs(output io.Writer, fields []FieldInfo) {
+	_, _ = fmt.Fprintln(output, ".SH OPTIONS")
+	for _, section := range groupFields(fields) {
+		if section[0].Group != "" {
+			_, _ = fmt.Fprintf(output, ".SS %s\n", troffEscape(section[0].Group))
+			if section[0].GroupDoc != "" {
+				_, _ = fmt.Fprintf(output, "%s\n", troffEscape(section[0].GroupDoc))
+			}
+		}
+
+		for _, field := range section {
+			if field.FlagName == "" && field.Position == "" {
+				continue
+			}
+
+			_, _ = fmt.Fprintf(output, ".TP\n\\fB%s\\fR \\fI%s\\fR\n", troffEscape(formatUsageRow(field)[0]), troffEscape(field.TypeName))
+			_, _ = fmt.Fprintf(output, "%s\n", troffEscape(formatUsageDoc(field)))
+			if field.HasDefault {
+				_, _ = fmt.Fprintf(output, ".br\nDefault: %s\n", troffEscape(displayDefault(field)))
+			}
+			if field.Env != "" {
+				_, _ = fmt.Fprintf(output, ".br\nEnvironment: \\fB%s\\fR\n", troffEscape(field.Env))
+			}
+		}
+	}
+
+	// The environment variables get their own section, as is customary for man pages.
+	_, _ = fmt.Fprintln(output, ".SH ENVIRONMENT")
+	for _, field := range fields {
+		if field.Env == "" {
+			continue
+		}
+		_, _ = fmt.Fprintf(output, ".TP\n\\fB%s\\fR\n%s\n", troffEscape(field.Env), troffEscape(formatUsageDoc(field)))
+	}
+}
+
+// formatDocsDescription creates the description of a field, which also shows its validations and example.
+// Unlike the help documentation, it does not show the required status, which has a column of its own.
+func formatDocsDescription(field FieldInfo) string {
+	field.Required = false
+	return formatUsageDoc(field)
+}
+
+// markdownCode wraps the value in backticks, unless it is empty.
+func markdownCode(value string) string {
+	if value == "" || value == notProvided {
+		return value
+	}
+	return "`" + value + "`"
+}
+
+// troffEscape escapes the characters that have a special meaning in troff.
+func troffEscape(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\e`)
+	value = strings.ReplaceAll(value, "-", `\-`)
+	// A line starting with a dot or an apostrophe is a troff request.
+	if strings.HasPrefix(value, ".") || strings.HasPrefix(value, "'") {
+		value = `\&` + value
+	}
+	return value
+}
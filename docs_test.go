@@ -0,0 +1,86 @@
+package confetti
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// dummyDocsTarget is the target used by the docs tests.
+type dummyDocsTarget struct {
+	LogLevel string `def:"info" env:"LOG_LEVEL" arg:"log-level,Log level" oneof:"debug info"`
+	HTTP     struct {
+		Port int `def:"8080" env:"HTTP_PORT" arg:"http-port,HTTP server port" required:"true"`
+	} `group:"HTTP,Configs of the HTTP server"`
+}
+
+// TestWriteDocs_Markdown tests if WriteDocs generates the Markdown tables correctly.
+func TestWriteDocs_Markdown(t *testing.T) {
+	expected := "| Flag | Env | Type | Default | Required | Description |\n" +
+		"| ---- | --- | ---- | ------- | -------- | ----------- |\n" +
+		"| `-log-level` | `LOG_LEVEL` | string | `info` | no | Log level [one of: debug, info] |\n" +
+		"\n" +
+		"### HTTP\n" +
+		"\n" +
+		"Configs of the HTTP server\n" +
+		"\n" +
+		"| Flag | Env | Type | Default | Required | Description |\n" +
+		"| ---- | --- | ---- | ------- | -------- | ----------- |\n" +
+		"| `-http-port` | `HTTP_PORT` | int | `8080` | yes | HTTP server port |\n"
+
+	output := &bytes.Buffer{}
+	if err := WriteDocs(output, &dummyDocsTarget{}, LoaderOptions{}, DocFormatMarkdown); err != nil {
+		t.Errorf("Expected WriteDocs error: nil, got: %+v", err)
+		return
+	}
+
+	if output.String() != expected {
+		t.Errorf("Expected markdown:\n%s\nbut got:\n%s", expected, output.String())
+	}
+}
+
+// TestWriteDocs_Man tests if WriteDocs generates the man page sections with escaped values.
+func TestWriteDocs_Man(t *testing.T) {
+	output := &bytes.Buffer{}
+	if err := WriteDocs(output, &dummyDocsTarget{}, LoaderOptions{}, DocFormatMan); err != nil {
+		t.Errorf("Expected WriteDocs error: nil, got: %+v", err)
+		return
+	}
+
+	for _, expected := range []string{".SH OPTIONS", `\fB\-log\-level\fR \fIstring\fR`, ".SS HTTP", ".SH ENVIRONMENT", `\fBHTTP_PORT\fR`} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected man page to contain: %s, but got:\n%s", expected, output.String())
+		}
+	}
+}
+
+// TestWriteDocs_UnknownFormat tests if WriteDocs gives an error for unknown formats.
+func TestWriteDocs_UnknownFormat(t *testing.T) {
+	if err := WriteDocs(&bytes.Buffer{}, &dummyDocsTarget{}, LoaderOptions{}, "html"); err == nil {
+		t.Errorf("Expected error from WriteDocs, but didn't get any.")
+	}
+}
+
+// TestGenerateDocs tests if GenerateDocs picks the format using the file extension.
+func TestGenerateDocs(t *testing.T) {
+	expectedPrefixes := map[string]string{"CONFIGS.md": "| Flag |", "app.1": ".SH OPTIONS", "configs.txt": "  FLAG"}
+
+	for name, prefix := range expectedPrefixes {
+		path := filepath.Join(t.TempDir(), name)
+		if err := GenerateDocs(path, &dummyDocsTarget{}, LoaderOptions{}); err != nil {
+			t.Errorf("Expected GenerateDocs error: nil, got: %+v", err)
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("Expected ReadFile error: nil, got: %+v", err)
+			continue
+		}
+		if !strings.HasPrefix(string(content), prefix) {
+			t.Errorf("Expected %s to start with: %s, but got:\n%s", name, prefix, content)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package confetti
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// dotEnvPlainValue matches the values that can be written in a .env file without quotes.
+var dotEnvPlainValue = regexp.MustCompile(`^[A-Za-z0-9_./:,@+-]*$`)
+
+// WriteDotEnvExample writes a commented .env.example file, listing the env variables of all fields of the target.
+//
+// Every variable is preceded by its doc. Variables with a default value are commented out, with the default
+// as their value, so the file works with the UseDotEnv option as is. Required variables without a default
+// are left uncommented and empty, to be filled in. The variables are grouped by nested struct.
+func WriteDotEnvExample(output io.Writer, target interface{}, opts LoaderOptions) error {
+	fields, err := Describe(target, opts)
+	if err != nil {
+		return err
+	}
+
+	writeDotEnvExample(output, fields)
+	return nil
+}
+
+// writeDotEnvExample writes the .env.example file for the given fields.
+func writeDotEnvExample(output io.Writer, fields []FieldInfo) {
+	// blocks are the paragraphs of the file, separated by blank lines.
+	var blocks []string
+
+	for _, section := range groupFields(fields) {
+		var sectionBlocks []string
+		for _, field := range section {
+			if field.Env != "" {
+				sectionBlocks = append(sectionBlocks, formatDotEnvBlock(field))
+			}
+		}
+
+		// Sections with no env variables are left out, along with their headings.
+		if len(sectionBlocks) == 0 {
+			continue
+		}
+		if section[0].Group != "" {
+			blocks = append(blocks, fmt.Sprintf("# --- %s ---\n", formatHeading(section[0])))
+		}
+		blocks = append(blocks, sectionBlocks...)
+	}
+
+	_, _ = fmt.Fprint(output, strings.Join(blocks, "\n"))
+}
+
+// formatDotEnvBlock creates the paragraph of a field, that is, its doc comment followed by the variable.
+func formatDotEnvBlock(field FieldInfo) string {
+	comment := formatUsageDoc(field)
+	// Unlike the help documentation, an absent doc is simply left out.
+	if field.Doc == "" {
+		comment = strings.TrimSpace(strings.TrimPrefix(comment, notProvided))
+	}
+
+	if comment == "" {
+		return formatDotEnvLine(field) + "\n"
+	}
+	return fmt.Sprintf("# %s\n%s\n", comment, formatDotEnvLine(field))
+}
+
+// formatDotEnvLine creates the variable assignment of the field, commented out unless it has to be filled in.
+// The default values of secret fields are left out.
+func formatDotEnvLine(field FieldInfo) string {
+	if field.HasDefault && !field.Secret {
+		return fmt.Sprintf("# %s=%s", field.Env, dotEnvQuote(field.Default))
+	}
+	if field.Required {
+		return fmt.Sprintf("%s=", field.Env)
+	}
+	return fmt.Sprintf("# %s=", field.Env)
+}
+
+// dotEnvQuote quotes the value, if required, so that it is read back as is from the .env file.
+func dotEnvQuote(value string) string {
+	if dotEnvPlainValue.MatchString(value) {
+		return value
+	}
+	// Single quoted values are read without any escaping.
+	if !strings.Contains(value, "'") {
+		return "'" + value + "'"
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
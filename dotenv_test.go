@@ -0,0 +1,71 @@
+package confetti
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/joho/godotenv"
+)
+
+// TestWriteDotEnvExample tests if WriteDotEnvExample writes the env variables grouped by nested struct.
+func TestWriteDotEnvExample(t *testing.T) {
+	dummyTarget := struct {
+		LogLevel string   `def:"info" env:"LOG_LEVEL" arg:"log-level,Log level" oneof:"debug info"`
+		Origins  []string `def:"[\"a.com\"]" env:"ORIGINS"`
+		NoEnv    string   `def:"1" arg:"no-env"`
+		HTTP     struct {
+			Port int    `env:"HTTP_PORT" arg:"http-port,HTTP server port" required:"true"`
+			Host string `env:"HTTP_HOST"`
+		} `group:"HTTP,Configs of the HTTP server"`
+		GRPC struct {
+			Port int `arg:"grpc-port"`
+		}
+	}{}
+
+	expected := `# Log level [one of: debug, info]
+# LOG_LEVEL=info
+
+# ORIGINS='["a.com"]'
+
+# --- HTTP: Configs of the HTTP server ---
+
+# HTTP server port [required]
+HTTP_PORT=
+
+# HTTP_HOST=
+`
+
+	output := &bytes.Buffer{}
+	if err := WriteDotEnvExample(output, &dummyTarget, LoaderOptions{}); err != nil {
+		t.Errorf("Expected WriteDotEnvExample error: nil, got: %+v", err)
+		return
+	}
+
+	if output.String() != expected {
+		t.Errorf("Expected .env.example:\n%s\nbut got:\n%s", expected, output.String())
+	}
+}
+
+// TestDotEnvQuote tests if the quoted values are read back as is by godotenv.
+func TestDotEnvQuote(t *testing.T) {
+	values := []string{"plain", "with space", `["a.com"]`, `it's "quoted"`, "http://localhost:8080/path"}
+
+	for _, value := range values {
+		path := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(path, []byte("KEY="+dotEnvQuote(value)+"\n"), 0o600); err != nil {
+			t.Errorf("Expected WriteFile error: nil, got: %+v", err)
+			return
+		}
+
+		parsed, err := godotenv.Read(path)
+		if err != nil {
+			t.Errorf("Expected godotenv.Read error: nil, got: %+v", err)
+			continue
+		}
+		if parsed["KEY"] != value {
+			t.Errorf("Expected value: %s, but got: %s", value, parsed["KEY"])
+		}
+	}
+}
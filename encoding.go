@@ -0,0 +1,67 @@
+package confetti
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// EncodingBase64 decodes the value as standard base64, with or without the padding. It is the default.
+	EncodingBase64 = "base64"
+	// EncodingBase64URL decodes the value as URL-safe base64, with or without the padding.
+	EncodingBase64URL = "base64url"
+	// EncodingHex decodes the value as hexadecimal.
+	EncodingHex = "hex"
+	// EncodingRaw takes the bytes of the value as they are.
+	EncodingRaw = "raw"
+)
+
+// jsonUnmarshalerType is the reflect.Type of json.Unmarshaler.
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// isBytesType returns true if the type is a byte slice, which is decoded as per the encoding tag.
+// Byte slices that unmarshal themselves, like json.RawMessage, are left to encoding/json.
+func isBytesType(fieldType reflect.Type) bool {
+	return fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Uint8 &&
+		!reflect.PtrTo(fieldType).Implements(jsonUnmarshalerType)
+}
+
+// bytesEncoding provides the encoding of the field as per its encoding tag, or EncodingBase64 if there is none.
+func bytesEncoding(opts *LoaderOptions, field rsf) string {
+	if encoding := field.Tag.Get(opts.EncodingTagName); encoding != "" {
+		return encoding
+	}
+	return EncodingBase64
+}
+
+// decodeBytes decodes the value as per the given encoding.
+func decodeBytes(encoding string, value string) ([]byte, error) {
+	switch encoding {
+	case EncodingBase64:
+		// The padding is optional, as it is often left out of keys and tokens.
+		return base64.RawStdEncoding.DecodeString(strings.TrimRight(value, "="))
+	case EncodingBase64URL:
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	case EncodingHex:
+		return hex.DecodeString(value)
+	case EncodingRaw:
+		return []byte(value), nil
+	default:
+		return nil, fmt.Errorf(`unknown encoding "%s", must be one of: %s, %s, %s, %s`,
+			encoding, EncodingBase64, EncodingBase64URL, EncodingHex, EncodingRaw)
+	}
+}
+
+// bytes2Interface decodes the value as per the given encoding and converts it to JSON.
+// The JSON form of a byte slice is its standard base64 encoding.
+func bytes2Interface(encoding string, value string) (interface{}, error) {
+	decoded, err := decodeBytes(encoding, value)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.EncodeToString(decoded), nil
+}
@@ -0,0 +1,111 @@
+package confetti
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestImplLoader_Load_Bytes tests if the byte slices are decoded as per their encoding tags.
+func TestImplLoader_Load_Bytes(t *testing.T) {
+	_ = os.Setenv("CONFETTI_BYTES_KEY", "aGVsbG8")
+	defer func() { _ = os.Unsetenv("CONFETTI_BYTES_KEY") }()
+
+	target := &struct {
+		Key     []byte          `env:"CONFETTI_BYTES_KEY"`
+		Padded  []byte          `def:"aGVsbG8="`
+		URL     []byte          `def:"-_8" encoding:"base64url"`
+		Hex     []byte          `arg:"hex" encoding:"hex"`
+		Raw     []byte          `def:"hello" encoding:"raw"`
+		Message json.RawMessage `def:"{\"a\":1}"`
+	}{}
+
+	opts := LoaderOptions{Args: []string{"-hex", "68656c6c6f"}}
+	if err := NewLoader(opts).Load(target); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+
+	expected := map[string][2][]byte{
+		"Key":    {[]byte("hello"), target.Key},
+		"Padded": {[]byte("hello"), target.Padded},
+		"URL":    {{0xfb, 0xff}, target.URL},
+		"Hex":    {[]byte("hello"), target.Hex},
+		"Raw":    {[]byte("hello"), target.Raw},
+		// Byte slices that unmarshal themselves are left to encoding/json.
+		"Message": {[]byte(`{"a":1}`), target.Message},
+	}
+	for name, values := range expected {
+		if !reflect.DeepEqual(values[0], values[1]) {
+			t.Errorf("Expected %s to be: %v, got: %v", name, values[0], values[1])
+		}
+	}
+}
+
+// TestImplLoader_Load_BytesPositional tests if the byte slices bound to positional arguments are decoded
+// as per their encoding tags too.
+func TestImplLoader_Load_BytesPositional(t *testing.T) {
+	target := &struct {
+		Key  []byte   `pos:"0"`
+		Hex  []byte   `pos:"1" encoding:"hex"`
+		Keys [][]byte `pos:"rest"`
+	}{}
+
+	opts := LoaderOptions{Args: []string{"aGVsbG8=", "68656c6c6f", "aGk", "aGV5"}}
+	if err := NewLoader(opts).Load(target); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+
+	if string(target.Key) != "hello" || string(target.Hex) != "hello" {
+		t.Errorf("Expected Key and Hex to be: hello, got: %s, %s", target.Key, target.Hex)
+	}
+	expected := [][]byte{[]byte("hi"), []byte("hey")}
+	if !reflect.DeepEqual(target.Keys, expected) {
+		t.Errorf("Expected Keys to be: %s, got: %s", expected, target.Keys)
+	}
+}
+
+// TestImplLoader_Load_BytesErrors tests if the invalid values and the unknown encodings result in errors.
+func TestImplLoader_Load_BytesErrors(t *testing.T) {
+	badHex := &struct {
+		Hex []byte `def:"xyz" encoding:"hex"`
+	}{}
+	if err := NewLoader(LoaderOptions{Args: []string{}}).Load(badHex); err == nil {
+		t.Errorf("Expected Load error for an invalid hex value, got: nil")
+	}
+
+	badEncoding := &struct {
+		Key []byte `def:"abc" encoding:"base32"`
+	}{}
+	err := NewLoader(LoaderOptions{Args: []string{}}).Load(badEncoding)
+	if err == nil || !strings.Contains(err.Error(), `unknown encoding "base32"`) {
+		t.Errorf("Expected Load error to name the unknown encoding, got: %+v", err)
+	}
+}
+
+// TestJSONSchema_Bytes tests if the contentEncoding of byte slices follows their encoding tags.
+func TestJSONSchema_Bytes(t *testing.T) {
+	dummyTarget := struct {
+		Key []byte `def:"aGVsbG8="`
+		Hex []byte `encoding:"hex"`
+		Raw []byte `encoding:"raw"`
+	}{}
+
+	schema, err := JSONSchema(&dummyTarget, LoaderOptions{})
+	if err != nil {
+		t.Errorf("Expected JSONSchema error: nil, got: %+v", err)
+		return
+	}
+
+	expected := msi{
+		"Key": msi{"type": "string", "contentEncoding": "base64", "default": "aGVsbG8="},
+		"Hex": msi{"type": "string", "contentEncoding": "base16"},
+		"Raw": msi{"type": "string"},
+	}
+	if !reflect.DeepEqual(schema["properties"], expected) {
+		t.Errorf("Expected properties to be: %+v, got: %+v", expected, schema["properties"])
+	}
+}
@@ -0,0 +1,126 @@
+package confetti
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// encryptedPrefix starts an encrypted value, as in "ENC[...]".
+	encryptedPrefix = "ENC["
+	// encryptedSuffix ends an encrypted value.
+	encryptedSuffix = "]"
+)
+
+// KeyProvider provides the key that decrypts the "ENC[...]" values.
+// The key must be 16, 24 or 32 bytes long, to select AES-128, AES-192 or AES-256.
+type KeyProvider interface {
+	// Key provides the decryption key.
+	Key() ([]byte, error)
+}
+
+// KeyFile is a KeyProvider that reads the key from the file at the given path.
+// The file holds the key in standard base64, optionally followed by a newline.
+//
+// A new key can be created with: head -c 32 /dev/urandom | base64 > confetti.key
+type KeyFile string
+
+// Key reads and decodes the key file.
+func (k KeyFile) Key() ([]byte, error) {
+	content, err := readValueFile(string(k))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	key, err := decodeBytes(EncodingBase64, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key file: %w", err)
+	}
+	return key, nil
+}
+
+// EncryptValue encrypts the value with the given key, so that it can be committed and later decrypted by
+// the DecryptionKey option. The result has the form "ENC[<base64>]", where the base64 part holds the
+// AES-GCM nonce followed by the ciphertext.
+func EncryptValue(key []byte, value string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(value), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed) + encryptedSuffix, nil
+}
+
+// decryptValue decrypts a value of the form "ENC[...]", as created by EncryptValue.
+func decryptValue(key []byte, value string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := strings.TrimSuffix(strings.TrimPrefix(value, encryptedPrefix), encryptedSuffix)
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode encrypted value: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("the encrypted value is too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		// The error of Open says nothing useful, and the likely cause is a wrong key.
+		return "", errors.New("failed to decrypt value: wrong key or corrupted value")
+	}
+	return string(plaintext), nil
+}
+
+// isEncrypted returns true if the value has the form "ENC[...]".
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix) && strings.HasSuffix(value, encryptedSuffix)
+}
+
+// newAEAD creates the AES-GCM cipher of the key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// decrypt decrypts the value with the DecryptionKey option, if the value is encrypted.
+// The key is provided only once per resolver, on the first encrypted value.
+//
+// An encrypted value without the DecryptionKey option is an error, so that the ciphertext never
+// becomes the value by mistake.
+func (i *implResolver) decrypt(stringValue string) (string, error) {
+	if !isEncrypted(stringValue) {
+		return stringValue, nil
+	}
+	if i.opts.DecryptionKey == nil {
+		return "", errors.New("the value is encrypted, but the DecryptionKey option is not set")
+	}
+
+	if i.key == nil {
+		key, err := i.opts.DecryptionKey.Key()
+		if err != nil {
+			return "", fmt.Errorf("failed to get decryption key: %w", err)
+		}
+		i.key = key
+	}
+	return decryptValue(i.key, stringValue)
+}
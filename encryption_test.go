@@ -0,0 +1,130 @@
+package confetti
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testKey is the AES-256 key used by the encryption tests.
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+// mockKeyProvider is a KeyProvider that counts its calls.
+type mockKeyProvider struct {
+	key   []byte
+	err   error
+	calls int
+}
+
+func (m *mockKeyProvider) Key() ([]byte, error) {
+	m.calls++
+	return m.key, m.err
+}
+
+// TestImplLoader_Load_Encrypted tests if the encrypted values are decrypted using a KeyFile.
+func TestImplLoader_Load_Encrypted(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "confetti.key")
+	if err := os.WriteFile(keyPath, []byte(base64.StdEncoding.EncodeToString(testKey)+"\n"), 0o600); err != nil {
+		t.Errorf("Expected WriteFile error: nil, got: %+v", err)
+		return
+	}
+
+	encrypted, err := EncryptValue(testKey, "s3cr3t")
+	if err != nil {
+		t.Errorf("Expected EncryptValue error: nil, got: %+v", err)
+		return
+	}
+	if !strings.HasPrefix(encrypted, "ENC[") || strings.Contains(encrypted, "s3cr3t") {
+		t.Errorf("Expected an ENC[...] value without the plaintext, got: %s", encrypted)
+	}
+
+	_ = os.Setenv("CONFETTI_ENC_PASSWORD", encrypted)
+	defer func() { _ = os.Unsetenv("CONFETTI_ENC_PASSWORD") }()
+
+	target := &struct {
+		Password string `env:"CONFETTI_ENC_PASSWORD"`
+		Plain    string `def:"plain"`
+	}{}
+
+	opts := LoaderOptions{DecryptionKey: KeyFile(keyPath), Args: []string{}}
+	if err := NewLoader(opts).Load(target); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+	if target.Password != "s3cr3t" {
+		t.Errorf("Expected Password to be: s3cr3t, got: %s", target.Password)
+	}
+	if target.Plain != "plain" {
+		t.Errorf("Expected Plain to be: plain, got: %s", target.Plain)
+	}
+}
+
+// TestImplLoader_Load_EncryptedKeyProvider tests if the key is requested only once,
+// and only if there are encrypted values.
+func TestImplLoader_Load_EncryptedKeyProvider(t *testing.T) {
+	first, _ := EncryptValue(testKey, "one")
+	second, _ := EncryptValue(testKey, "two")
+
+	provider := &mockKeyProvider{key: testKey}
+	target := &struct {
+		First  string `arg:"first"`
+		Second string `arg:"second"`
+	}{}
+
+	opts := LoaderOptions{DecryptionKey: provider, Args: []string{"-first", first, "-second", second}}
+	if err := NewLoader(opts).Load(target); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+	if target.First != "one" || target.Second != "two" {
+		t.Errorf("Expected First and Second to be: one, two, got: %s, %s", target.First, target.Second)
+	}
+	if provider.calls != 1 {
+		t.Errorf("Expected Key calls: 1, got: %d", provider.calls)
+	}
+
+	// Without encrypted values, the key is never requested.
+	unused := &mockKeyProvider{err: errors.New("no key")}
+	plain := &struct {
+		Value string `def:"plain"`
+	}{}
+	if err := NewLoader(LoaderOptions{DecryptionKey: unused, Args: []string{}}).Load(plain); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+	}
+	if unused.calls != 0 {
+		t.Errorf("Expected Key calls: 0, got: %d", unused.calls)
+	}
+}
+
+// TestImplLoader_Load_EncryptedErrors tests if a wrong key or a missing key provider result in errors.
+func TestImplLoader_Load_EncryptedErrors(t *testing.T) {
+	encrypted, _ := EncryptValue(testKey, "s3cr3t")
+	target := &struct {
+		Password string `arg:"password"`
+	}{}
+
+	wrongKey := &mockKeyProvider{key: []byte("fedcba9876543210fedcba9876543210")}
+	err := NewLoader(LoaderOptions{DecryptionKey: wrongKey, Args: []string{"-password", encrypted}}).Load(target)
+	if err == nil || !strings.Contains(err.Error(), "wrong key or corrupted value") {
+		t.Errorf("Expected Load error for a wrong key, got: %+v", err)
+	}
+
+	// Without the DecryptionKey option, the ciphertext never becomes the value.
+	err = NewLoader(LoaderOptions{Args: []string{"-password", encrypted}}).Load(target)
+	if err == nil || !strings.Contains(err.Error(), "the DecryptionKey option is not set") {
+		t.Errorf("Expected Load error for a missing key, got: %+v", err)
+	}
+	if target.Password != "" {
+		t.Errorf("Expected Password to be empty, got: %s", target.Password)
+	}
+}
+
+// TestEncryptValue_InvalidKey tests if a key of an invalid length results in an error.
+func TestEncryptValue_InvalidKey(t *testing.T) {
+	if _, err := EncryptValue([]byte("short"), "value"); err == nil {
+		t.Errorf("Expected EncryptValue error for a short key, got: nil")
+	}
+}
@@ -0,0 +1,58 @@
+package confetti_test
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/shivanshkc/confetti/v2"
+)
+
+// exampleConfigs stands for the configs of an application, which usually live in a package of their own.
+type exampleConfigs struct {
+	LogLevel string `def:"info" env:"LOG_LEVEL" arg:"log-level,Log level" oneof:"debug info warn error"`
+	HTTP     struct {
+		Port int `def:"8080" env:"HTTP_PORT" arg:"http-port,HTTP server port"`
+	}
+}
+
+// ExampleGenerateDocs shows the program that "go generate" runs to keep the docs of the configs up to date.
+//
+// The program is saved as gen_docs.go in the package of the configs, with a "//go:build ignore" line
+// at the top, so that it is not part of the package. It is run by the following directive,
+// placed in any other file of the package:
+//
+//	//go:generate go run gen_docs.go
+//
+// The program writes CONFIGS.md in the package directory. Here, it writes into a temporary directory instead,
+// and prints the result.
+func ExampleGenerateDocs() {
+	dir, err := os.MkdirTemp("", "confetti-docs")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path := filepath.Join(dir, "CONFIGS.md")
+	if err := confetti.GenerateDocs(path, &exampleConfigs{}, confetti.LoaderOptions{}); err != nil {
+		log.Fatal(err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(string(content))
+
+	// Output:
+	// | Flag | Env | Type | Default | Required | Description |
+	// | ---- | --- | ---- | ------- | -------- | ----------- |
+	// | `-log-level` | `LOG_LEVEL` | string | `info` | no | Log level [one of: debug, info, warn, error] |
+	//
+	// ### HTTP
+	//
+	// | Flag | Env | Type | Default | Required | Description |
+	// | ---- | --- | ---- | ------- | -------- | ----------- |
+	// | `-http-port` | `HTTP_PORT` | int | `8080` | no | HTTP server port |
+}
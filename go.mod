@@ -3,3 +3,5 @@ module github.com/shivanshkc/confetti/v2
 go 1.17
 
 require github.com/joho/godotenv v1.4.0
+
+require github.com/spf13/pflag v1.0.5
@@ -0,0 +1,56 @@
+package confetti
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// Holder keeps the current configs (a struct pointer) for lock-free reads, as services do on every request.
+//
+// The configs are swapped as a whole, so the readers never see a half-updated struct. The held struct must
+// be treated as read-only; a new struct is swapped in instead of modifying the held one.
+// The zero value is an empty Holder, ready to use. A Holder must not be copied after first use.
+type Holder struct {
+	// value keeps the struct pointer.
+	value atomic.Value
+}
+
+// Load loads the configs into a new instance of the target type using the loader, like ILoader.Load,
+// and then holds that instance. If loading fails, the Holder is not changed.
+//
+// The target (a struct pointer) only provides the type, it is never modified. So, the held configs can be
+// given as the target to reload them, as the readers never see them being loaded.
+func (h *Holder) Load(loader ILoader, target interface{}) error {
+	if !isStructPointer(target) {
+		return errors.New("target must be a struct pointer")
+	}
+
+	configs := reflect.New(reflect.TypeOf(target).Elem()).Interface()
+	if err := loader.Load(configs); err != nil {
+		return err
+	}
+
+	_, err := h.Swap(configs)
+	return err
+}
+
+// Get provides the held configs, or nil if the Holder is empty.
+// The result can be asserted to the type of the held struct pointer, like holder.Get().(*Configs).
+func (h *Holder) Get() interface{} {
+	return h.value.Load()
+}
+
+// Swap holds the given configs and provides the previously held ones, which are nil if the Holder was empty.
+// The configs must be a struct pointer of the same type as the held one.
+func (h *Holder) Swap(configs interface{}) (interface{}, error) {
+	if !isStructPointer(configs) {
+		return nil, errors.New("configs must be a struct pointer")
+	}
+	if held := h.value.Load(); held != nil && reflect.TypeOf(held) != reflect.TypeOf(configs) {
+		return nil, fmt.Errorf("configs must be of type %T, got: %T", held, configs)
+	}
+
+	return h.value.Swap(configs), nil
+}
@@ -0,0 +1,117 @@
+package confetti
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// heldConfigs is the target of the Holder tests.
+type heldConfigs struct {
+	LogLevel string `def:"info"`
+	RPS      int    `def:"10"`
+}
+
+// TestHolder_Load tests if the Holder holds the loaded configs, and stays empty if loading fails.
+func TestHolder_Load(t *testing.T) {
+	holder := &Holder{}
+	if holder.Get() != nil {
+		t.Errorf("Expected an empty Holder to provide nil, got: %+v", holder.Get())
+	}
+
+	badTarget := &struct {
+		RPS int `def:"many"`
+	}{}
+	if err := holder.Load(NewLoader(LoaderOptions{Args: []string{}}), badTarget); err == nil {
+		t.Errorf("Expected Load error for an invalid default, got: nil")
+	}
+	if holder.Get() != nil {
+		t.Errorf("Expected the Holder to stay empty, got: %+v", holder.Get())
+	}
+
+	target := &heldConfigs{}
+	if err := holder.Load(NewLoader(LoaderOptions{Args: []string{}}), target); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+	if held := holder.Get().(*heldConfigs); held == target || held.LogLevel != "info" {
+		t.Errorf("Expected the Holder to hold new loaded configs, got: %+v", held)
+	}
+	if target.LogLevel != "" {
+		t.Errorf("Expected the target to be unchanged, got: %+v", target)
+	}
+}
+
+// TestHolder_Load_Held tests if loading with the held configs as the target leaves them unchanged,
+// so that their readers never see them being loaded.
+func TestHolder_Load_Held(t *testing.T) {
+	holder := &Holder{}
+	held := &heldConfigs{LogLevel: "debug", RPS: 1}
+	_, _ = holder.Swap(held)
+
+	if err := holder.Load(NewLoader(LoaderOptions{Args: []string{}}), held); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+	if held.LogLevel != "debug" || held.RPS != 1 {
+		t.Errorf("Expected the previously held configs to be unchanged, got: %+v", held)
+	}
+	if reloaded := holder.Get().(*heldConfigs); reloaded == held || reloaded.LogLevel != "info" || reloaded.RPS != 10 {
+		t.Errorf("Expected the Holder to hold new loaded configs, got: %+v", reloaded)
+	}
+
+	if err := holder.Load(NewLoader(LoaderOptions{Args: []string{}}), heldConfigs{}); err == nil {
+		t.Errorf("Expected Load error for a non-pointer, got: nil")
+	}
+}
+
+// TestHolder_Swap tests if Swap provides the previous configs and rejects the configs of another type.
+func TestHolder_Swap(t *testing.T) {
+	holder := &Holder{}
+	first, second := &heldConfigs{RPS: 1}, &heldConfigs{RPS: 2}
+
+	if old, err := holder.Swap(first); err != nil || old != nil {
+		t.Errorf("Expected Swap to provide: nil, nil, got: %+v, %+v", old, err)
+	}
+	if old, err := holder.Swap(second); err != nil || old.(*heldConfigs) != first {
+		t.Errorf("Expected Swap to provide the first configs, got: %+v, %+v", old, err)
+	}
+
+	if _, err := holder.Swap(heldConfigs{}); err == nil {
+		t.Errorf("Expected Swap error for a non-pointer, got: nil")
+	}
+	_, err := holder.Swap(&struct{ Other string }{})
+	if err == nil || !strings.Contains(err.Error(), "configs must be of type *confetti.heldConfigs") {
+		t.Errorf("Expected Swap error for another type, got: %+v", err)
+	}
+	if holder.Get().(*heldConfigs) != second {
+		t.Errorf("Expected the Holder to keep the second configs, got: %+v", holder.Get())
+	}
+}
+
+// TestHolder_Concurrent tests if the readers always see complete configs while they are swapped.
+func TestHolder_Concurrent(t *testing.T) {
+	holder := &Holder{}
+	_, _ = holder.Swap(&heldConfigs{LogLevel: "0", RPS: 0})
+
+	var waitGroup sync.WaitGroup
+	for reader := 0; reader < 4; reader++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for ind := 0; ind < 1000; ind++ {
+				configs := holder.Get().(*heldConfigs)
+				if configs.LogLevel != strconv.Itoa(configs.RPS) {
+					t.Errorf("Expected consistent configs, got: %+v", configs)
+					return
+				}
+			}
+		}()
+	}
+
+	for ind := 1; ind <= 1000; ind++ {
+		_, _ = holder.Swap(&heldConfigs{LogLevel: strconv.Itoa(ind), RPS: ind})
+	}
+	waitGroup.Wait()
+}
@@ -1,11 +1,25 @@
 package confetti
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	"os"
+	"reflect"
 )
 
+// versionFlagName is the name of the flag that prints the version, when the Version option is set.
+const versionFlagName = "version"
+
+// versionFlagDoc is the usage info of the version flag.
+const versionFlagDoc = "Print the version and exit."
+
+// printConfigFlagName is the name of the flag that prints the effective configs,
+// when the PrintConfigFlag option is set.
+const printConfigFlagName = "print-config"
+
+// printConfigFlagDoc is the usage info of the print-config flag.
+const printConfigFlagDoc = "Print the effective configs with their sources and exit. Use -print-config=json for JSON."
+
 // implFlagger implements iFlagger.
 type implFlagger struct {
 	// opts keeps the LoaderOptions.
@@ -14,39 +28,87 @@ type implFlagger struct {
 	flagSet *flag.FlagSet
 	// flags keeps track of all flag values.
 	flags map[string]*customFlagHolder
+	// positionals keeps track of the fields bound to positional arguments.
+	positionals *positionalTracker
+	// external is true if the flagSet is provided by the user.
+	// An external flagSet is parsed by the user, and it may contain flags that confetti did not register.
+	external bool
+
+	// args are the arguments to be parsed, without the program name.
+	args []string
+	// doc is the usage info of the command that this flagger parses, if any.
+	doc string
+	// commands are the subcommands that are listed on "-h", if any.
+	commands []Command
+	// infos keeps the FieldInfo of all registered fields, for the help documentation.
+	infos []FieldInfo
+	// printConfig is set by the print-config flag.
+	printConfig printConfigValue
+}
+
+// newArgsFlagger returns a new implFlagger that parses the provided args.
+func newArgsFlagger(opts *LoaderOptions, title string, doc string, args []string) *implFlagger {
+	instance := &implFlagger{
+		opts:        opts,
+		flagSet:     flag.NewFlagSet(title, flag.ContinueOnError),
+		flags:       map[string]*customFlagHolder{},
+		positionals: newPositionalTracker(),
+		args:        args,
+		doc:         doc,
+	}
+
+	instance.flagSet.Usage = instance.usage
+	if opts.Output != nil {
+		instance.flagSet.SetOutput(opts.Output)
+	}
+	return instance
 }
 
 func (i *implFlagger) RegisterField(parents []rsf, field rsf) error {
-	// Getting the value of the arg tag.
-	argTagValue, present := field.Tag.Lookup(i.opts.ArgTagName)
-	if !present || argTagValue == "" {
-		return nil
+	// Positional arguments are tracked separately from the flags.
+	if err := i.positionals.register(i.opts, parents, field); err != nil {
+		return err
 	}
 
-	// The flagName is the name of the flag to be parsed.
-	// The flagDoc is the usage info of the flag.
-	flagName, flagDoc := getFlagNameAndDoc(argTagValue, ",")
-	if flagName == "" {
+	// The info contains the flagName, which is the name of the flag to be parsed,
+	// and the Doc, which is the usage info of the flag.
+	info := newFieldInfo(i.opts, parents, field)
+	// The flagSet panics on a redefined flag, so two fields with the same flag are reported instead.
+	if existing := i.lookupInfo(info.FlagName); existing != nil {
+		return fmt.Errorf(`flag "%s" is declared by both fields "%s" and "%s"`, info.FlagName, existing.Path, info.Path)
+	}
+	if field.Type.Kind() != reflect.Struct {
+		i.infos = append(i.infos, info)
+	}
+	if info.FlagName == "" {
 		return nil
 	}
+
+	flagName, flagDoc := info.FlagName, info.Doc
 	if flagDoc == "" {
 		flagDoc = "not provided"
 	}
 
 	// Using the def and env tag values to show even more info on "-h".
-	defValue, present := field.Tag.Lookup(i.opts.DefTagName)
-	if !present {
+	defValue := displayDefault(info)
+	if !info.HasDefault {
 		defValue = "not provided"
 	}
 
-	envValue, present := field.Tag.Lookup(i.opts.EnvTagName)
-	if !present || envValue == "" {
+	envValue := info.Env
+	if envValue == "" {
 		envValue = "not provided"
 	}
 
 	// The usage instructions that will show up on "-h".
 	usage := fmt.Sprintf("Doc: %s\nDefault: %s\nEnvironment: %s", flagDoc, defValue, envValue)
 
+	// An external flagSet may already have this flag, either defined by the user or by an earlier
+	// RegisterFlags call. In that case, the existing flag is used as is.
+	if i.external && i.flagSet.Lookup(flagName) != nil {
+		return nil
+	}
+
 	// Binding the flag values to customFlagHolder.
 	i.flags[flagName] = &customFlagHolder{}
 	i.flagSet.Var(i.flags[flagName], flagName, usage)
@@ -55,13 +117,165 @@ func (i *implFlagger) RegisterField(parents []rsf, field rsf) error {
 }
 
 func (i *implFlagger) Parse() error {
-	if err := i.flagSet.Parse(os.Args[1:]); err != nil {
+	// An external flagSet is parsed by the user.
+	if i.external {
+		if !i.flagSet.Parsed() {
+			return errors.New("the provided FlagSet must be parsed before calling Load")
+		}
+		return nil
+	}
+
+	// The version flag is added only if the user has not defined a flag with the same name.
+	var versionRequested *bool
+	if i.opts.Version != "" && i.flagSet.Lookup(versionFlagName) == nil {
+		versionRequested = i.flagSet.Bool(versionFlagName, false, versionFlagDoc)
+		i.infos = append(i.infos, FieldInfo{FlagName: versionFlagName, Doc: versionFlagDoc, Type: reflect.TypeOf(false)})
+	}
+
+	// The print-config flag is added in the same way as the version flag.
+	if i.opts.PrintConfigFlag && i.flagSet.Lookup(printConfigFlagName) == nil {
+		i.flagSet.Var(&i.printConfig, printConfigFlagName, printConfigFlagDoc)
+		i.infos = append(i.infos, FieldInfo{
+			FlagName: printConfigFlagName,
+			Doc:      printConfigFlagDoc,
+			Type:     reflect.TypeOf(""),
+			OneOf:    []string{string(ConfigFormatText), string(ConfigFormatJSON)},
+		})
+	}
+
+	err := i.flagSet.Parse(i.args)
+	if errors.Is(err, flag.ErrHelp) {
+		// The help documentation has already been printed by the flagSet.
+		return i.exitOrReturn(ErrHelp)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to parse flags: %w", err)
 	}
+
+	if versionRequested != nil && *versionRequested {
+		_, _ = fmt.Fprintf(i.flagSet.Output(), "%s %s\n", i.flagSet.Name(), i.opts.Version)
+		return i.exitOrReturn(ErrVersion)
+	}
 	return nil
 }
 
+// lookupInfo provides the FieldInfo of the registered field with the given flag name, or nil if there is none.
+func (i *implFlagger) lookupInfo(flagName string) *FieldInfo {
+	if flagName == "" {
+		return nil
+	}
+	for ind := range i.infos {
+		if i.infos[ind].FlagName == flagName {
+			return &i.infos[ind]
+		}
+	}
+	return nil
+}
+
+// exitOrReturn exits the program with code 0 if the ExitOnHelp option is set, otherwise it returns the err.
+func (i *implFlagger) exitOrReturn(err error) error {
+	if i.opts.ExitOnHelp {
+		osExit(0)
+	}
+	return err
+}
+
 func (i *implFlagger) LookupFlag(flagName string) (flagValue string, exists bool) {
+	if i.external {
+		return i.lookupExternalFlag(flagName)
+	}
+
 	holder, exists := i.flags[flagName]
 	return holder.String(), exists && holder.exists
 }
+
+func (i *implFlagger) LookupPositional(position string) (values []string, exists bool) {
+	return i.positionals.lookup(i.flagSet.Args(), position)
+}
+
+// usage prints the help documentation of the flagSet using the Usage option or DefaultUsage.
+func (i *implFlagger) usage() {
+	usage := i.opts.Usage
+	if usage == nil {
+		usage = DefaultUsage
+	}
+	usage(i.flagSet.Output(), UsageInfo{Title: i.flagSet.Name(), Doc: i.doc, Fields: i.infos, Commands: i.commands})
+}
+
+// lookupExternalFlag looks up a flag inside an external flagSet.
+// It does not rely on customFlagHolder because the flag may have been defined by the user.
+func (i *implFlagger) lookupExternalFlag(flagName string) (flagValue string, exists bool) {
+	flg := i.flagSet.Lookup(flagName)
+	if flg == nil {
+		return "", false
+	}
+
+	// Visit only visits the flags that have been set.
+	i.flagSet.Visit(func(visited *flag.Flag) {
+		if visited.Name == flagName {
+			exists = true
+		}
+	})
+
+	return flg.Value.String(), exists
+}
+
+// implSourceFlagger implements iFlagger using a user provided FlagSource.
+// The FlagSource is managed by the user, so there is nothing to register or parse.
+type implSourceFlagger struct {
+	// opts keeps the LoaderOptions.
+	opts *LoaderOptions
+	// source provides all flag values.
+	source FlagSource
+	// positionals keeps track of the fields bound to positional arguments.
+	positionals *positionalTracker
+}
+
+func (i *implSourceFlagger) RegisterField(parents []rsf, field rsf) error {
+	// Only the positional arguments are tracked, as the flags are managed by the user.
+	return i.positionals.register(i.opts, parents, field)
+}
+
+func (i *implSourceFlagger) Parse() error { return nil }
+
+func (i *implSourceFlagger) LookupFlag(flagName string) (flagValue string, exists bool) {
+	return i.source.LookupFlag(flagName)
+}
+
+func (i *implSourceFlagger) LookupPositional(position string) (values []string, exists bool) {
+	// The source provides positional arguments only if it has an Args method.
+	argsSource, ok := i.source.(interface{ Args() []string })
+	if !ok {
+		return nil, false
+	}
+	return i.positionals.lookup(argsSource.Args(), position)
+}
+
+// implChainFlagger implements iFlagger using multiple flaggers.
+// The value is looked up in all flaggers in order, and the first one that has it wins.
+type implChainFlagger struct {
+	// flaggers are the already parsed flaggers.
+	flaggers []iFlagger
+}
+
+func (i *implChainFlagger) RegisterField(_ []rsf, _ rsf) error { return nil }
+
+func (i *implChainFlagger) Parse() error { return nil }
+
+func (i *implChainFlagger) LookupFlag(flagName string) (flagValue string, exists bool) {
+	for _, flagger := range i.flaggers {
+		if flagValue, exists = flagger.LookupFlag(flagName); exists {
+			return flagValue, true
+		}
+	}
+	return "", false
+}
+
+func (i *implChainFlagger) LookupPositional(position string) (values []string, exists bool) {
+	for _, flagger := range i.flaggers {
+		if values, exists = flagger.LookupPositional(position); exists {
+			return values, true
+		}
+	}
+	return nil, false
+}
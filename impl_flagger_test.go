@@ -1,7 +1,10 @@
 package confetti
 
 import (
+	"bytes"
 	"flag"
+	"fmt"
+	"io"
 	"reflect"
 	"strings"
 	"testing"
@@ -150,3 +153,243 @@ func TestImplFlagger_LookupFlag(t *testing.T) {
 		}
 	}
 }
+
+// TestImplFlagger_ExternalFlagSet tests if the flagger works correctly with a user provided FlagSet.
+func TestImplFlagger_ExternalFlagSet(t *testing.T) {
+	flagSet := flag.NewFlagSet("external", flag.ContinueOnError)
+	// A flag defined by the user, that confetti should reuse.
+	userFlag := flagSet.String("df-2", "user-default", "defined by the user")
+	// A flag unrelated to confetti.
+	flagSet.Bool("verbose", false, "unrelated to confetti")
+
+	instance := newFlagger(&LoaderOptions{ArgTagName: "arg", DefTagName: "def", EnvTagName: "env", FlagSet: flagSet})
+
+	dummyTarget := struct {
+		dummyField1 string `arg:"df-1"`
+		dummyField2 string `arg:"df-2"`
+		dummyField3 string `arg:"df-3"`
+	}{}
+
+	structValue := reflect.ValueOf(dummyTarget)
+	structType := structValue.Type()
+
+	for ind := 0; ind < structValue.NumField(); ind++ {
+		fieldType := structType.Field(ind)
+		if err := instance.RegisterField(nil, &fieldType); err != nil {
+			t.Errorf("Expected RegisterField error: nil, got: %+v", err)
+			return
+		}
+	}
+
+	// Load must not be possible before the user parses the FlagSet.
+	if err := instance.Parse(); err == nil {
+		t.Errorf("Expected Parse error for unparsed FlagSet, but didn't get any.")
+		return
+	}
+
+	if err := flagSet.Parse([]string{"-verbose", "-df-1", "one", "-df-2", "two"}); err != nil {
+		t.Errorf("Expected FlagSet.Parse error: nil, got: %+v", err)
+		return
+	}
+	if err := instance.Parse(); err != nil {
+		t.Errorf("Expected Parse error: nil, got: %+v", err)
+		return
+	}
+
+	if value, exists := instance.LookupFlag("df-1"); !exists || value != "one" {
+		t.Errorf("Expected df-1 to be: one, got: %s (exists: %t)", value, exists)
+	}
+	if value, exists := instance.LookupFlag("df-2"); !exists || value != "two" || *userFlag != "two" {
+		t.Errorf("Expected df-2 to be: two, got: %s (exists: %t)", value, exists)
+	}
+	if _, exists := instance.LookupFlag("df-3"); exists {
+		t.Errorf("Expected df-3 to not exist since it was not provided, but it exists.")
+	}
+}
+
+// TestImplSourceFlagger_LookupFlag tests if the source flagger delegates lookups to the FlagSource.
+func TestImplSourceFlagger_LookupFlag(t *testing.T) {
+	instance := newFlagger(&LoaderOptions{FlagSource: &implMockFlagger{argMap: map[string]string{"df-1": "one"}}})
+
+	if err := instance.Parse(); err != nil {
+		t.Errorf("Expected Parse error: nil, got: %+v", err)
+		return
+	}
+
+	if value, exists := instance.LookupFlag("df-1"); !exists || value != "one" {
+		t.Errorf("Expected df-1 to be: one, got: %s (exists: %t)", value, exists)
+	}
+	if _, exists := instance.LookupFlag("df-2"); exists {
+		t.Errorf("Expected df-2 to not exist, but it exists.")
+	}
+}
+
+// TestImplFlagger_Usage tests if the help documentation lists the doc and the subcommands.
+func TestImplFlagger_Usage(t *testing.T) {
+	instance := newArgsFlagger(defaultLoaderOptions, "configs serve", "Starts the server.", nil)
+	instance.commands = []Command{{Name: "serve", Doc: "Starts the server."}}
+
+	output := &bytes.Buffer{}
+	instance.flagSet.SetOutput(output)
+	instance.flagSet.Usage()
+
+	for _, expected := range []string{"Usage of configs serve:", "Starts the server.", "Commands:", "serve"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected usage to contain: %s, but got: %s", expected, output.String())
+		}
+	}
+}
+
+// TestImplChainFlagger_LookupFlag tests if the chain flagger gives precedence to the earlier flaggers.
+func TestImplChainFlagger_LookupFlag(t *testing.T) {
+	instance := &implChainFlagger{flaggers: []iFlagger{
+		&implMockFlagger{argMap: map[string]string{"df-1": "first"}},
+		&implMockFlagger{argMap: map[string]string{"df-1": "second", "df-2": "second"}},
+	}}
+
+	if value, exists := instance.LookupFlag("df-1"); !exists || value != "first" {
+		t.Errorf("Expected df-1 to be: first, got: %s (exists: %t)", value, exists)
+	}
+	if value, exists := instance.LookupFlag("df-2"); !exists || value != "second" {
+		t.Errorf("Expected df-2 to be: second, got: %s (exists: %t)", value, exists)
+	}
+	if _, exists := instance.LookupFlag("df-3"); exists {
+		t.Errorf("Expected df-3 to not exist, but it exists.")
+	}
+}
+
+// TestImplFlagger_LookupPositional tests if positional arguments are looked up correctly after parsing.
+func TestImplFlagger_LookupPositional(t *testing.T) {
+	instance := newArgsFlagger(defaultLoaderOptions, "configs", "", []string{"-df-1", "one", "a", "b", "c", "d"})
+
+	dummyTarget := struct {
+		dummyField1 string   `arg:"df-1"`
+		dummyField2 string   `pos:"0"`
+		dummyField3 string   `pos:"01"`
+		dummyField4 []string `pos:"rest"`
+	}{}
+
+	structValue := reflect.ValueOf(dummyTarget)
+	structType := structValue.Type()
+
+	for ind := 0; ind < structValue.NumField(); ind++ {
+		fieldType := structType.Field(ind)
+		if err := instance.RegisterField(nil, &fieldType); err != nil {
+			t.Errorf("Expected RegisterField error: nil, got: %+v", err)
+			return
+		}
+	}
+
+	if err := instance.Parse(); err != nil {
+		t.Errorf("Expected Parse error: nil, got: %+v", err)
+		return
+	}
+
+	expected := map[string][]string{"0": {"a"}, "1": {"b"}, "rest": {"c", "d"}}
+	for position, expectedValues := range expected {
+		values, exists := instance.LookupPositional(position)
+		if !exists || !reflect.DeepEqual(values, expectedValues) {
+			t.Errorf("Expected position %s to be: %+v, got: %+v (exists: %t)", position, expectedValues, values, exists)
+		}
+	}
+
+	if _, exists := instance.LookupPositional("5"); exists {
+		t.Errorf("Expected position 5 to not exist, but it exists.")
+	}
+}
+
+// TestImplFlagger_RegisterField_BadPosition tests if RegisterField rejects invalid and duplicate positions.
+func TestImplFlagger_RegisterField_BadPosition(t *testing.T) {
+	dummyTarget := struct {
+		dummyField1 string `pos:"first"`
+		dummyField2 string `pos:"-1"`
+		dummyField3 string `pos:"0"`
+		dummyField4 string `pos:"00"`
+	}{}
+
+	structType := reflect.TypeOf(dummyTarget)
+	instance := newArgsFlagger(defaultLoaderOptions, "configs", "", nil)
+
+	// The expected errors, one per field.
+	expectErr := []bool{true, true, false, true}
+
+	for ind := 0; ind < structType.NumField(); ind++ {
+		fieldType := structType.Field(ind)
+		err := instance.RegisterField(nil, &fieldType)
+		if expectErr[ind] != (err != nil) {
+			t.Errorf("Unexpected RegisterField error for field %s: %+v", fieldType.Name, err)
+		}
+	}
+}
+
+// TestImplFlagger_Parse_Help tests if the help flag writes to the Output option and gives ErrHelp.
+func TestImplFlagger_Parse_Help(t *testing.T) {
+	output := &bytes.Buffer{}
+	opts := &LoaderOptions{Title: "configs", ArgTagName: "arg", PosTagName: "pos", Output: output}
+	instance := newArgsFlagger(opts, opts.Title, "", []string{"-h"})
+
+	if err := instance.Parse(); err != ErrHelp {
+		t.Errorf("Expected Parse error: %+v, got: %+v", ErrHelp, err)
+	}
+	if !strings.Contains(output.String(), "Usage of configs:") {
+		t.Errorf("Expected output to contain the usage, but got: %s", output.String())
+	}
+}
+
+// TestImplFlagger_Parse_Version tests if the version flag prints the version and gives ErrVersion.
+func TestImplFlagger_Parse_Version(t *testing.T) {
+	output := &bytes.Buffer{}
+	opts := &LoaderOptions{Title: "configs", ArgTagName: "arg", PosTagName: "pos", Output: output, Version: "v1.2.3"}
+	instance := newArgsFlagger(opts, opts.Title, "", []string{"-version"})
+
+	if err := instance.Parse(); err != ErrVersion {
+		t.Errorf("Expected Parse error: %+v, got: %+v", ErrVersion, err)
+	}
+	if output.String() != "configs v1.2.3\n" {
+		t.Errorf("Expected output to be the version, but got: %s", output.String())
+	}
+}
+
+// TestImplFlagger_Parse_ExitOnHelp tests if the program exits with code 0 when the ExitOnHelp option is set.
+func TestImplFlagger_Parse_ExitOnHelp(t *testing.T) {
+	defer func(exit func(int)) { osExit = exit }(osExit)
+
+	exitCode := -1
+	osExit = func(code int) { exitCode = code }
+
+	opts := &LoaderOptions{Title: "configs", ArgTagName: "arg", PosTagName: "pos", Output: &bytes.Buffer{}, ExitOnHelp: true}
+	instance := newArgsFlagger(opts, opts.Title, "", []string{"-help"})
+
+	_ = instance.Parse()
+	if exitCode != 0 {
+		t.Errorf("Expected exit code: 0, got: %d", exitCode)
+	}
+}
+
+// TestImplFlagger_Usage_Custom tests if the Usage option replaces the default help documentation.
+func TestImplFlagger_Usage_Custom(t *testing.T) {
+	output := &bytes.Buffer{}
+	opts := &LoaderOptions{Title: "configs", ArgTagName: "arg", PosTagName: "pos", Output: output}
+	opts.Usage = func(output io.Writer, info UsageInfo) {
+		for _, field := range info.Fields {
+			_, _ = fmt.Fprintf(output, "%s:%s\n", info.Title, field.FlagName)
+		}
+	}
+
+	instance := newArgsFlagger(opts, opts.Title, "", nil)
+
+	dummyTarget := struct {
+		dummyField1 string `arg:"df-1"`
+	}{}
+
+	fieldType := reflect.TypeOf(dummyTarget).Field(0)
+	if err := instance.RegisterField(nil, &fieldType); err != nil {
+		t.Errorf("Expected RegisterField error: nil, got: %+v", err)
+		return
+	}
+
+	instance.flagSet.Usage()
+	if output.String() != "configs:df-1\n" {
+		t.Errorf("Expected the custom usage, but got: %s", output.String())
+	}
+}
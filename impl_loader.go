@@ -4,62 +4,305 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"reflect"
 
 	"github.com/joho/godotenv"
 )
 
-// implLoader implements ILoader.
-type implLoader struct {
+// Loader implements ILoader. It is provided by New.
+//
+// A Loader can be used for any number of calls, including concurrent ones.
+type Loader struct {
 	// opts keeps the LoaderOptions.
 	opts *LoaderOptions
 
-	flagger  iFlagger
-	resolver iResolver
+	// flaggerProvider provides a fresh iFlagger for every Load call.
+	// The iFlagger is stateful, so it is never shared between calls.
+	flaggerProvider func() iFlagger
+	// resolverProvider provides a fresh iResolver for every Load call.
+	// The iResolver keeps a snapshot of the ConfigDir, so it is never shared between calls either.
+	resolverProvider func() iResolver
+	// lookupEnv, if not nil, looks up the environment variables in place of the process environment,
+	// and the .env file is not loaded into the process environment. It is set for the reloads of a Watcher only.
+	lookupEnv envLookup
+}
+
+// Load loads the configs into the provided target.
+func (i *Loader) Load(target interface{}) error {
+	_, err := i.LoadWithReport(target)
+	return err
+}
+
+// LoadWithReport is the same as Load, but it also reports how the value of every field was resolved,
+// that is, the raw value and the source that provided it. The reports are in the order of declaration.
+func (i *Loader) LoadWithReport(target interface{}) ([]FieldReport, error) {
+	reports, flagger, err := i.load(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.printConfigIfRequested(reports, flagger); err != nil {
+		return nil, err
+	}
+	return reports, nil
 }
 
-func (i *implLoader) Load(target interface{}) error {
+// load loads the configs into the target and reports the source of every field.
+// It also provides the flagger that was used, which knows whether the configs should be printed.
+func (i *Loader) load(target interface{}) ([]FieldReport, iFlagger, error) {
 	// Validations.
 	if !isStructPointer(target) {
-		return errors.New("target must be a struct pointer")
+		return nil, nil, errors.New("target must be a struct pointer")
+	}
+
+	// Reading the .env file as per the option.
+	if i.opts.UseDotEnv && i.lookupEnv == nil {
+		_ = godotenv.Load(dotEnvFile)
+	}
+
+	// Every call gets its own flagger, so that repeated and concurrent calls do not interfere.
+	flagger := i.flaggerProvider()
+
+	// Creating the flagSet.
+	if err := i.registerFields(target, flagger); err != nil {
+		return nil, nil, err
+	}
+
+	// Parsing all the flags.
+	if err := flagger.Parse(); err != nil {
+		return nil, nil, err
+	}
+
+	reports, err := i.resolveFields(target, flagger, i.resolverProvider())
+	if err != nil {
+		return nil, nil, err
+	}
+	return reports, flagger, nil
+}
+
+// LoadCommand loads the configs of a program that has subcommands.
+//
+// The first positional argument selects the command. The flags before it are the parent's flags,
+// and the flags after it can be the parent's or the command's flags. The parent target, if not nil,
+// is loaded along with the target of the selected command. The name of that command is returned.
+func (i *Loader) LoadCommand(parent interface{}, commands ...Command) (string, error) {
+	// Validations.
+	if parent != nil && !isStructPointer(parent) {
+		return "", errors.New("parent must be a struct pointer or nil")
+	}
+	if i.opts.FlagSet != nil || i.opts.FlagSource != nil {
+		return "", errors.New("LoadCommand cannot be used with the FlagSet or FlagSource options")
+	}
+	for _, cmd := range commands {
+		if cmd.Name == "" || !isStructPointer(cmd.Target) {
+			return "", fmt.Errorf(`command "%s" must have a name and a struct pointer target`, cmd.Name)
+		}
 	}
 
 	// Reading the .env file as per the option.
 	if i.opts.UseDotEnv {
-		_ = godotenv.Load()
+		_ = godotenv.Load(dotEnvFile)
+	}
+
+	// The parent flags are parsed first. Parsing stops at the first positional argument, which is the command.
+	parentFlagger := newArgsFlagger(i.opts, i.opts.Title, "", i.opts.getArgs())
+	parentFlagger.commands = commands
+
+	if err := i.registerFields(parent, parentFlagger); err != nil {
+		return "", err
+	}
+	if err := parentFlagger.Parse(); err != nil {
+		return "", err
+	}
+
+	// Looking up the command using the first positional argument.
+	remaining := parentFlagger.flagSet.Args()
+	if len(remaining) == 0 {
+		parentFlagger.flagSet.Usage()
+		return "", errors.New("no command provided")
+	}
+
+	var command *Command
+	for ind := range commands {
+		if commands[ind].Name == remaining[0] {
+			command = &commands[ind]
+			break
+		}
+	}
+	if command == nil {
+		parentFlagger.flagSet.Usage()
+		return "", fmt.Errorf(`unknown command: "%s"`, remaining[0])
+	}
+
+	// The command flagSet contains the parent flags as well, so they can be provided after the command too.
+	// So, the command cannot declare the same flags as the parent.
+	if err := checkCommandFlags(parentFlagger, command); err != nil {
+		return "", err
+	}
+	title := fmt.Sprintf("%s %s", i.opts.Title, command.Name)
+	commandFlagger := newArgsFlagger(i.opts, title, command.Doc, remaining[1:])
+
+	if err := i.registerFields(parent, commandFlagger); err != nil {
+		return "", err
+	}
+	if err := i.registerFields(command.Target, commandFlagger); err != nil {
+		return "", err
+	}
+	if err := commandFlagger.Parse(); err != nil {
+		return "", err
+	}
+
+	// Parent flags provided after the command take precedence over the ones provided before it.
+	// Both targets share the resolver, so they see the same snapshot of the ConfigDir.
+	resolver := i.resolverProvider()
+	parentReports, err := i.resolveFields(parent, &implChainFlagger{flaggers: []iFlagger{commandFlagger, parentFlagger}}, resolver)
+	if err != nil {
+		return "", err
+	}
+	commandReports, err := i.resolveFields(command.Target, commandFlagger, resolver)
+	if err != nil {
+		return "", err
+	}
+
+	// The print-config flag can be given either before or after the command.
+	printFlagger := commandFlagger
+	if commandFlagger.printConfig.format == "" {
+		printFlagger = parentFlagger
+	}
+	if err := i.printConfigIfRequested(append(parentReports, commandReports...), printFlagger); err != nil {
+		return "", err
+	}
+
+	return command.Name, nil
+}
+
+// RegisterFlags registers the flags of the provided target into the FlagSet given in the LoaderOptions.
+// It is required only when the FlagSet option is used, and it should be called before parsing the FlagSet.
+func (i *Loader) RegisterFlags(target interface{}) error {
+	// Validations.
+	if i.opts.FlagSet == nil {
+		return errors.New("RegisterFlags requires the FlagSet option")
+	}
+	if !isStructPointer(target) {
+		return errors.New("target must be a struct pointer")
 	}
 
 	// Getting the value out of the struct pointer to loop over its fields.
 	structValue := reflect.ValueOf(target).Elem().Interface()
 
-	// Creating the flagSet.
-	if err := i.forEachStructField(structValue, i.flagger.RegisterField, nil); err != nil {
-		return fmt.Errorf("failed to create flagSet: %w", err)
+	// Registering all fields into the user's FlagSet.
+	if err := i.forEachStructField(structValue, i.flaggerProvider().RegisterField, nil); err != nil {
+		return fmt.Errorf("failed to register flags: %w", err)
 	}
 
-	// Parsing all the flags.
-	if err := i.flagger.Parse(); err != nil {
+	return nil
+}
+
+// checkCommandFlags returns an error if a field of the command declares the same flag as a field of the parent,
+// which is already registered into the parentFlagger.
+func checkCommandFlags(parentFlagger *implFlagger, command *Command) error {
+	fields, err := Describe(command.Target, *parentFlagger.opts)
+	if err != nil {
 		return err
 	}
 
+	for _, field := range fields {
+		if existing := parentFlagger.lookupInfo(field.FlagName); existing != nil {
+			return fmt.Errorf(`flag "%s" is declared by both the parent field "%s" and the field "%s" of command "%s"`,
+				field.FlagName, existing.Path, field.Path, command.Name)
+		}
+	}
+	return nil
+}
+
+// registerFields registers all fields of the target (a struct pointer) into the flagger.
+// A nil target has no fields.
+func (i *Loader) registerFields(target interface{}, flagger iFlagger) error {
+	if target == nil {
+		return nil
+	}
+
+	// Getting the value out of the struct pointer to loop over its fields.
+	structValue := reflect.ValueOf(target).Elem().Interface()
+
+	if err := i.forEachStructField(structValue, flagger.RegisterField, nil); err != nil {
+		return fmt.Errorf("failed to create flagSet: %w", err)
+	}
+	return nil
+}
+
+// resolveFields resolves all fields of the target (a struct pointer) and loads them into the target.
+// It provides the source of every non-struct field. A nil target has no fields.
+func (i *Loader) resolveFields(target interface{}, flagger iFlagger, resolver iResolver) ([]FieldReport, error) {
+	if target == nil {
+		return nil, nil
+	}
+
+	// Getting the value out of the struct pointer to loop over its fields.
+	structValue := reflect.ValueOf(target).Elem().Interface()
+
+	// All fields are registered first, as a field may refer to the ones declared after it.
+	if err := i.forEachStructField(structValue, resolver.RegisterField, nil); err != nil {
+		return nil, fmt.Errorf("failed to register fields: %w", err)
+	}
+
 	targetMap := msi{}
+	var reports []FieldReport
 	// Loading all values inside the targetMap.
-	if err := i.forEachStructField(structValue, i.resolveFieldWrapper(targetMap), nil); err != nil {
-		return fmt.Errorf("failed to resolve values: %w", err)
+	if err := i.forEachStructField(structValue, i.resolveFieldWrapper(targetMap, &reports, flagger, resolver), nil); err != nil {
+		return nil, fmt.Errorf("failed to resolve values: %w", err)
 	}
 
 	// Marshalling the targetMap values into JSON.
 	targetJSON, err := json.Marshal(targetMap)
 	if err != nil {
-		return fmt.Errorf("failed to marshal the targetMap: %w", err)
+		return nil, fmt.Errorf("failed to marshal the targetMap: %w", err)
 	}
 
 	// Finally, unmarshalling the JSON into the target struct.
 	if err := json.Unmarshal(targetJSON, target); err != nil {
-		return fmt.Errorf("failed to unmarshal into target: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal into target: %w", err)
 	}
 
-	return nil
+	// The environment variables may have been set by the .env file.
+	if i.opts.UseDotEnv {
+		attributeDotEnv(reports, dotEnvFile, i.getLookupEnv())
+	}
+	// The raw values are redacted only now, as the .env file attribution compares them.
+	redactReports(reports)
+	return reports, nil
+}
+
+// withLookupEnv provides a copy of the Loader that looks up the environment variables with the given function,
+// and that never loads the .env file into the process environment.
+func (i *Loader) withLookupEnv(lookupEnv envLookup) *Loader {
+	loader := *i
+	loader.lookupEnv = lookupEnv
+	loader.resolverProvider = func() iResolver { return newResolver(i.opts, lookupEnv) }
+	return &loader
+}
+
+// getLookupEnv provides the function that looks up the environment variables.
+func (i *Loader) getLookupEnv() envLookup {
+	if i.lookupEnv != nil {
+		return i.lookupEnv
+	}
+	return os.LookupEnv
+}
+
+// printConfigIfRequested prints the effective configs if the print-config flag was given to the flagger.
+func (i *Loader) printConfigIfRequested(reports []FieldReport, flagger iFlagger) error {
+	// Only the flaggers that parse the args have the print-config flag.
+	argsFlagger, ok := flagger.(*implFlagger)
+	if !ok || argsFlagger.printConfig.format == "" {
+		return nil
+	}
+
+	if err := writeConfig(argsFlagger.flagSet.Output(), reports, argsFlagger.printConfig.format); err != nil {
+		return err
+	}
+	return argsFlagger.exitOrReturn(ErrPrintConfig)
 }
 
 // forEachStructField loops over all the fields of the provided input (struct)
@@ -71,7 +314,7 @@ func (i *implLoader) Load(target interface{}) error {
 //
 // The "parents" argument is received by recursive calls made internally.
 // External calls should provide this value as nil.
-func (i *implLoader) forEachStructField(value interface{}, action structFieldAction, parents []rsf) error {
+func (i *Loader) forEachStructField(value interface{}, action structFieldAction, parents []rsf) error {
 	// Creating reflection types for looping over fields.
 	reflectValue := reflect.ValueOf(value)
 	reflectType := reflect.TypeOf(value)
@@ -100,8 +343,9 @@ func (i *implLoader) forEachStructField(value interface{}, action structFieldAct
 			continue
 		}
 
-		// Appending the parent before doing the recursive call.
-		newParents := append(parents, &fieldReflectType)
+		// Appending the parent before doing the recursive call. The parents are copied, as the actions may keep them,
+		// and the sibling structs would overwrite each other's parent if they shared the backing array.
+		newParents := append(append([]rsf{}, parents...), &fieldReflectType)
 		// Looping over the nested struct fields.
 		if err := i.forEachStructField(fieldReflectValue.Interface(), action, newParents); err != nil {
 			return err
@@ -111,16 +355,25 @@ func (i *implLoader) forEachStructField(value interface{}, action structFieldAct
 	return nil
 }
 
-// resolveFieldWrapper is a wrapper around the iResolver.ResolveField method to
-// make it a valid structFieldAction while also putting the targetMap in the scope.
-func (i *implLoader) resolveFieldWrapper(targetMap msi) structFieldAction {
+// resolveFieldWrapper is a wrapper around the iResolver.ResolveField method to make it a valid
+// structFieldAction while also putting the targetMap, the reports, the flagger and the resolver in the scope.
+func (i *Loader) resolveFieldWrapper(targetMap msi, reports *[]FieldReport, flagger iFlagger,
+	resolver iResolver) structFieldAction {
 	return func(parents []rsf, field rsf) error {
 		// Getting the resolved value.
-		resolved, err := i.resolver.ResolveField(parents, field, i.flagger)
+		resolved, source, err := resolver.ResolveField(parents, field, flagger)
 		if err != nil {
 			return err
 		}
 
+		// Nested structs are not fields of their own, so they are not reported.
+		if field.Type.Kind() != reflect.Struct {
+			report := newFieldReport(formatNestedFieldName(parents, field), source)
+			// The values interpolated from secret fields are secrets too.
+			report.Secret = isSecret(i.opts, field) || source.secret
+			*reports = append(*reports, report)
+		}
+
 		// Creating a separate map.
 		// So, we won't lose the reference to the original targetMap.
 		nestedMap := targetMap
@@ -1,14 +1,20 @@
 package confetti
 
 import (
+	"bytes"
 	"errors"
+	"flag"
+	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
 // implMockFlagger is a mock implementation of iFlagger.
 type implMockFlagger struct {
 	argMap      map[string]string
+	posMap      map[string][]string
 	registerErr error
 }
 
@@ -21,28 +27,35 @@ func (i *implMockFlagger) LookupFlag(flagName string) (flagValue string, exists
 	return value, exists
 }
 
+func (i *implMockFlagger) LookupPositional(position string) (values []string, exists bool) {
+	values, exists = i.posMap[position]
+	return values, exists
+}
+
 // implMockResolver is a mock implementation of iResolver.
 type implMockResolver struct {
 	errorMap map[string]error
 	valueMap map[string]interface{}
 }
 
-func (i *implMockResolver) ResolveField(parents []rsf, field rsf, flagger iFlagger) (resolved interface{}, err error) {
+func (i *implMockResolver) RegisterField(_ []rsf, _ rsf) error { return nil }
+
+func (i *implMockResolver) ResolveField(parents []rsf, field rsf, flagger iFlagger) (interface{}, fieldSource, error) {
 	err, exists := i.errorMap[field.Name]
 	if exists {
-		return nil, err
+		return nil, fieldSource{}, err
 	}
 	value, exists := i.valueMap[field.Name]
 	if exists {
-		return value, nil
+		return value, fieldSource{kind: SourceDefault, raw: fmt.Sprint(value)}, nil
 	}
-	return nil, nil
+	return nil, fieldSource{kind: SourceNone}, nil
 }
 
 // TestImplLoader_Load_NotStructPointer tests if the Load method gives
 // an error when it is invoked without a struct pointer.
 func TestImplLoader_Load_NotStructPointer(t *testing.T) {
-	instance := &implLoader{}
+	instance := &Loader{}
 	if err := instance.Load(2); err == nil {
 		t.Errorf("Expected error from Load, but didn't get any.")
 		return
@@ -65,19 +78,20 @@ func TestImplLoader_Load(t *testing.T) {
 	dummyField21Expected := 10
 	dummyField221Expected := map[string]string{"cool": "right"}
 
-	instance := &implLoader{
+	instance := &Loader{
 		opts: defaultLoaderOptions,
-		flagger: &implMockFlagger{
-			argMap:      map[string]string{},
-			registerErr: nil,
+		flaggerProvider: func() iFlagger {
+			return &implMockFlagger{argMap: map[string]string{}, registerErr: nil}
 		},
-		resolver: &implMockResolver{
-			errorMap: map[string]error{},
-			valueMap: map[string]interface{}{
-				"DummyField1":   dummyField1Expected,
-				"DummyField21":  dummyField21Expected,
-				"DummyField221": dummyField221Expected,
-			},
+		resolverProvider: func() iResolver {
+			return &implMockResolver{
+				errorMap: map[string]error{},
+				valueMap: map[string]interface{}{
+					"DummyField1":   dummyField1Expected,
+					"DummyField21":  dummyField21Expected,
+					"DummyField221": dummyField221Expected,
+				},
+			}
 		},
 	}
 
@@ -105,10 +119,10 @@ func TestImplLoader_Load_PointerError(t *testing.T) {
 		}
 	}{}
 
-	instance := &implLoader{
-		opts:     defaultLoaderOptions,
-		flagger:  &implMockFlagger{},
-		resolver: &implMockResolver{},
+	instance := &Loader{
+		opts:             defaultLoaderOptions,
+		flaggerProvider:  func() iFlagger { return &implMockFlagger{} },
+		resolverProvider: func() iResolver { return &implMockResolver{} },
 	}
 
 	if err := instance.Load(&dummyTarget); err == nil {
@@ -123,9 +137,11 @@ func TestImplLoader_Load_FlaggerError(t *testing.T) {
 		DummyField1 string
 	}{}
 
-	instance := &implLoader{
-		opts:    defaultLoaderOptions,
-		flagger: &implMockFlagger{registerErr: errors.New("failed to register")},
+	instance := &Loader{
+		opts: defaultLoaderOptions,
+		flaggerProvider: func() iFlagger {
+			return &implMockFlagger{registerErr: errors.New("failed to register")}
+		},
 	}
 
 	if err := instance.Load(&dummyTarget); err == nil {
@@ -140,11 +156,11 @@ func TestImplLoader_Load_ResolverError(t *testing.T) {
 		DummyField1 string
 	}{}
 
-	instance := &implLoader{
-		opts:    defaultLoaderOptions,
-		flagger: &implMockFlagger{},
-		resolver: &implMockResolver{
-			errorMap: map[string]error{"DummyField1": errors.New("failed to resolve field")},
+	instance := &Loader{
+		opts:            defaultLoaderOptions,
+		flaggerProvider: func() iFlagger { return &implMockFlagger{} },
+		resolverProvider: func() iResolver {
+			return &implMockResolver{errorMap: map[string]error{"DummyField1": errors.New("failed to resolve field")}}
 		},
 	}
 
@@ -153,3 +169,250 @@ func TestImplLoader_Load_ResolverError(t *testing.T) {
 		return
 	}
 }
+
+// TestImplLoader_Load_Reuse tests if the same loader can be used for multiple Load calls.
+func TestImplLoader_Load_Reuse(t *testing.T) {
+	// Empty args, so that the flagSet does not complain about the test flags.
+	loader := NewLoader(LoaderOptions{Args: []string{}})
+
+	for ind := 0; ind < 3; ind++ {
+		dummyTarget := struct {
+			DummyField1 string `def:"dummy1" env:"CONFETTI_REUSE_DF1" arg:"confetti-reuse-df-1"`
+		}{}
+
+		if err := loader.Load(&dummyTarget); err != nil {
+			t.Errorf("Expected error to be nil on Load call %d, but got: %+v", ind, err)
+			return
+		}
+		if dummyTarget.DummyField1 != "dummy1" {
+			t.Errorf("Expected DummyField1 value to be: dummy1, got: %+v", dummyTarget.DummyField1)
+			return
+		}
+	}
+}
+
+// TestImplLoader_Load_Concurrent tests if the same loader can be used by multiple goroutines at once.
+func TestImplLoader_Load_Concurrent(t *testing.T) {
+	// Empty args, so that the flagSet does not complain about the test flags.
+	loader := NewLoader(LoaderOptions{Args: []string{}})
+
+	waitGroup := &sync.WaitGroup{}
+	errChan := make(chan error, 10)
+
+	for ind := 0; ind < 10; ind++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+
+			dummyTarget := struct {
+				DummyField1 int `def:"10" env:"CONFETTI_CONCURRENT_DF1" arg:"confetti-concurrent-df-1"`
+			}{}
+			if err := loader.Load(&dummyTarget); err != nil {
+				errChan <- err
+				return
+			}
+			if dummyTarget.DummyField1 != 10 {
+				errChan <- fmt.Errorf("expected DummyField1 value to be: 10, got: %+v", dummyTarget.DummyField1)
+			}
+		}()
+	}
+
+	waitGroup.Wait()
+	close(errChan)
+
+	for err := range errChan {
+		t.Errorf("Expected error to be nil, but got: %+v", err)
+	}
+}
+
+// TestImplLoader_RegisterFlags tests if the RegisterFlags and Load methods work with a user provided FlagSet.
+func TestImplLoader_RegisterFlags(t *testing.T) {
+	flagSet := flag.NewFlagSet("external", flag.ContinueOnError)
+	verbose := flagSet.Bool("verbose", false, "unrelated to confetti")
+
+	loader := New(LoaderOptions{FlagSet: flagSet})
+
+	dummyTarget := struct {
+		DummyField1 int    `def:"1" arg:"df-1"`
+		DummyField2 string `def:"2" arg:"df-2"`
+	}{}
+
+	if err := loader.RegisterFlags(&dummyTarget); err != nil {
+		t.Errorf("Expected RegisterFlags error: nil, got: %+v", err)
+		return
+	}
+	// Registering again should not panic due to redefinition.
+	if err := loader.RegisterFlags(&dummyTarget); err != nil {
+		t.Errorf("Expected second RegisterFlags error: nil, got: %+v", err)
+		return
+	}
+
+	if err := flagSet.Parse([]string{"-verbose", "-df-1", "10"}); err != nil {
+		t.Errorf("Expected FlagSet.Parse error: nil, got: %+v", err)
+		return
+	}
+
+	if err := loader.Load(&dummyTarget); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+
+	if !*verbose {
+		t.Errorf("Expected the unrelated verbose flag to be parsed, but it was not.")
+	}
+	if dummyTarget.DummyField1 != 10 {
+		t.Errorf("Expected DummyField1 value to be: 10, got: %+v", dummyTarget.DummyField1)
+	}
+	if dummyTarget.DummyField2 != "2" {
+		t.Errorf("Expected DummyField2 value to be: 2, got: %+v", dummyTarget.DummyField2)
+	}
+}
+
+// TestImplLoader_RegisterFlags_NoFlagSet tests if RegisterFlags gives an error when the FlagSet option is absent.
+func TestImplLoader_RegisterFlags_NoFlagSet(t *testing.T) {
+	dummyTarget := struct {
+		DummyField1 string `arg:"df-1"`
+	}{}
+
+	if err := New(LoaderOptions{}).RegisterFlags(&dummyTarget); err == nil {
+		t.Errorf("Expected error from RegisterFlags, but didn't get any.")
+		return
+	}
+}
+
+// commandTargets provides the parent and command targets used by the LoadCommand tests.
+func commandTargets() (*dummyParent, *dummyServe, *dummyMigrate, []Command) {
+	parent, serve, migrate := &dummyParent{}, &dummyServe{}, &dummyMigrate{}
+	return parent, serve, migrate, []Command{
+		{Name: "serve", Doc: "Starts the server.", Target: serve},
+		{Name: "migrate", Doc: "Runs the migrations.", Target: migrate},
+	}
+}
+
+type dummyParent struct {
+	LogLevel string `def:"info" arg:"log-level"`
+}
+
+type dummyServe struct {
+	Port int `def:"8080" arg:"port"`
+}
+
+type dummyMigrate struct {
+	Steps int `def:"1" arg:"steps"`
+}
+
+// TestImplLoader_LoadCommand tests if LoadCommand loads the parent and the selected command.
+func TestImplLoader_LoadCommand(t *testing.T) {
+	parent, serve, migrate, commands := commandTargets()
+	loader := New(LoaderOptions{Args: []string{"-log-level", "debug", "serve", "-port", "80"}})
+
+	name, err := loader.LoadCommand(parent, commands...)
+	if err != nil {
+		t.Errorf("Expected LoadCommand error: nil, got: %+v", err)
+		return
+	}
+
+	if name != "serve" {
+		t.Errorf("Expected command to be: serve, got: %s", name)
+	}
+	if parent.LogLevel != "debug" {
+		t.Errorf("Expected LogLevel to be: debug, got: %s", parent.LogLevel)
+	}
+	if serve.Port != 80 {
+		t.Errorf("Expected Port to be: 80, got: %d", serve.Port)
+	}
+	// The migrate command was not selected, so it should stay untouched.
+	if migrate.Steps != 0 {
+		t.Errorf("Expected Steps to be: 0, got: %d", migrate.Steps)
+	}
+}
+
+// TestImplLoader_LoadCommand_ParentFlagAfterCommand tests if parent flags work after the command too.
+func TestImplLoader_LoadCommand_ParentFlagAfterCommand(t *testing.T) {
+	parent, _, migrate, commands := commandTargets()
+	loader := New(LoaderOptions{Args: []string{"-log-level", "debug", "migrate", "-log-level", "warn"}})
+
+	if _, err := loader.LoadCommand(parent, commands...); err != nil {
+		t.Errorf("Expected LoadCommand error: nil, got: %+v", err)
+		return
+	}
+
+	if parent.LogLevel != "warn" {
+		t.Errorf("Expected LogLevel to be: warn, got: %s", parent.LogLevel)
+	}
+	if migrate.Steps != 1 {
+		t.Errorf("Expected Steps to be: 1, got: %d", migrate.Steps)
+	}
+}
+
+// TestImplLoader_LoadCommand_BadCommand tests if LoadCommand gives an error for a missing or unknown command.
+func TestImplLoader_LoadCommand_BadCommand(t *testing.T) {
+	for _, args := range [][]string{{}, {"-log-level", "debug"}, {"unknown"}} {
+		parent, _, _, commands := commandTargets()
+		// The help output is not required here.
+		loader := New(LoaderOptions{Args: args, Output: &bytes.Buffer{}})
+
+		if _, err := loader.LoadCommand(parent, commands...); err == nil {
+			t.Errorf("Expected LoadCommand error for args: %+v, but didn't get any.", args)
+		}
+	}
+}
+
+// TestImplLoader_LoadCommand_FlagCollision tests if a command flag that collides with a parent flag
+// results in an error instead of a panic.
+func TestImplLoader_LoadCommand_FlagCollision(t *testing.T) {
+	parent := &struct {
+		Port int `arg:"port"`
+	}{}
+	serve := &struct {
+		HTTP struct {
+			Port int `arg:"port"`
+		}
+	}{}
+
+	loader := New(LoaderOptions{Args: []string{"serve", "-port", "80"}})
+	_, err := loader.LoadCommand(parent, Command{Name: "serve", Target: serve})
+	expected := `flag "port" is declared by both the parent field "Port" and the field "HTTP.Port" of command "serve"`
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected LoadCommand error: %s, got: %+v", expected, err)
+	}
+}
+
+// TestImplLoader_Load_FlagCollision tests if two fields with the same flag result in an error instead of a panic.
+func TestImplLoader_Load_FlagCollision(t *testing.T) {
+	target := &struct {
+		Port  int `arg:"port"`
+		Admin struct {
+			Port int `arg:"port"`
+		}
+	}{}
+
+	err := NewLoader(LoaderOptions{Args: []string{}}).Load(target)
+	if err == nil || !strings.Contains(err.Error(), `flag "port" is declared by both fields "Port" and "Admin.Port"`) {
+		t.Errorf("Expected Load error for a flag collision, got: %+v", err)
+	}
+}
+
+// TestImplLoader_Load_Positional tests if Load binds positional arguments and reports the missing ones.
+func TestImplLoader_Load_Positional(t *testing.T) {
+	dummyTarget := struct {
+		Verbose bool     `arg:"verbose"`
+		Source  string   `pos:"0"`
+		Files   []string `pos:"rest"`
+	}{}
+
+	loader := NewLoader(LoaderOptions{Args: []string{"-verbose=true", "src", "a.txt", "b.txt"}})
+	if err := loader.Load(&dummyTarget); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+
+	if !dummyTarget.Verbose || dummyTarget.Source != "src" || !reflect.DeepEqual(dummyTarget.Files, []string{"a.txt", "b.txt"}) {
+		t.Errorf("Unexpected loaded values: %+v", dummyTarget)
+	}
+
+	loader = NewLoader(LoaderOptions{Args: []string{"-verbose=true"}})
+	if err := loader.Load(&dummyTarget); err == nil {
+		t.Errorf("Expected Load error for missing positional, but didn't get any.")
+	}
+}
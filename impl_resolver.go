@@ -2,59 +2,263 @@ package confetti
 
 import (
 	"fmt"
-	"os"
+	"reflect"
+	"strconv"
+	"strings"
 )
 
+// envFileSuffix is appended to the name of an environment variable to get the one that holds its file path.
+const envFileSuffix = "_FILE"
+
+// envLookup looks up the value of an environment variable, like os.LookupEnv.
+type envLookup func(key string) (value string, present bool)
+
 // implResolver implements iResolver.
 type implResolver struct {
 	// opts keeps the LoaderOptions.
 	opts *LoaderOptions
+	// lookupEnv looks up the environment variables.
+	lookupEnv envLookup
+	// configDir provides the values of the ConfigDir option. It is nil if the option is not set.
+	configDir *dirSource
+	// fields keeps all registered fields by their paths, so that they can be referenced by interpolation.
+	fields map[string]fieldRef
+	// key is the decryption key, once provided by the DecryptionKey option.
+	key []byte
+	// expanded caches the expanded values, so that every value is expanded only once per resolver.
+	expanded map[expandedKey]string
 }
 
-func (i *implResolver) ResolveField(parents []rsf, field rsf, flagger iFlagger) (interface{}, error) {
-	resolveErr := fmt.Errorf(`failed to resolve field: "%s"`, formatNestedFieldName(parents, field))
+// expandedKey identifies an expanded value by the path of its field and the value before the expansion.
+type expandedKey struct {
+	// path is the path of the field.
+	path string
+	// value is the value before the expansion.
+	value string
+}
 
-	stringValue, present := i.resolveArg(field, flagger)
-	if present {
-		value, err := string2Interface(field.Type.Kind(), stringValue)
-		return value, checkAndWrapErr(err, resolveErr)
+func (i *implResolver) RegisterField(parents []rsf, field rsf) error {
+	// Only the fields with values of their own can be referenced.
+	if i.opts.Interpolate && field.Type.Kind() != reflect.Struct {
+		i.fields[formatNestedFieldName(parents, field)] = fieldRef{parents: parents, field: field}
 	}
+	return nil
+}
 
-	stringValue, present = i.resolveEnv(field)
-	if present {
-		value, err := string2Interface(field.Type.Kind(), stringValue)
-		return value, checkAndWrapErr(err, resolveErr)
+func (i *implResolver) ResolveField(parents []rsf, field rsf, flagger iFlagger) (interface{}, fieldSource, error) {
+	path := formatNestedFieldName(parents, field)
+	resolveErr := fmt.Errorf(`failed to resolve field: "%s"`, path)
+
+	stringValues, source, present, err := i.resolveRaw(parents, field, flagger, []string{path})
+	if err != nil {
+		return nil, source, checkAndWrapErr(err, resolveErr)
 	}
 
-	stringValue, present = i.resolveDef(field)
 	if present {
-		value, err := string2Interface(field.Type.Kind(), stringValue)
-		return value, checkAndWrapErr(err, resolveErr)
+		var value interface{}
+		if source.kind == SourcePositional {
+			value, err = i.convertPositionals(path, field, stringValues)
+		} else {
+			value, err = i.convert(path, field, stringValues[0])
+		}
+		// A value that was interpolated from a secret field is redacted like the secret itself.
+		if err != nil && source.secret {
+			err = errRedacted
+		}
+		return value, source, checkAndWrapErr(err, resolveErr)
 	}
 
-	return nil, nil
+	// No source has a value, which is an error only for the required fields.
+	return nil, source, checkAndWrapErr(i.checkMissing(field), resolveErr)
 }
 
-func (i *implResolver) resolveArg(field rsf, flagger iFlagger) (string, bool) {
+// resolveRaw provides the string values of the field from the first source that has them,
+// in the order of precedence: arg, pos, env, config dir and def.
+//
+// The values of the env, config dir and def sources are interpolated if the Interpolate option is set.
+// The values of the command-line are not, as they have been expanded by the shell already.
+// The stack holds the paths of the fields that are being resolved, for the detection of cycles.
+func (i *implResolver) resolveRaw(parents []rsf, field rsf, flagger iFlagger, stack []string) ([]string, fieldSource, bool, error) {
+	if stringValue, source, present := i.resolveArg(field, flagger); present {
+		return []string{stringValue}, source, true, nil
+	}
+
+	if stringValues, source, present := i.resolvePos(field, flagger); present {
+		return stringValues, source, true, nil
+	}
+
+	stringValue, source, present, err := i.resolveEnv(field)
+	if err == nil && !present {
+		stringValue, source, present, err = i.resolveConfigDir(parents, field)
+	}
+	if err == nil && !present {
+		stringValue, source, present = i.resolveDef(field)
+	}
+
+	switch {
+	case err != nil:
+		return nil, source, false, err
+	case !present:
+		return nil, fieldSource{kind: SourceNone}, false, nil
+	case i.opts.Interpolate:
+		interpolated, secret, err := i.interpolate(stringValue, flagger, stack)
+		if err != nil {
+			return nil, source, false, err
+		}
+		// The source reports the final value, unless it reports the path of a file that held the value.
+		if source.raw == stringValue {
+			source.raw = interpolated
+		}
+		source.secret = secret
+		stringValue = interpolated
+	}
+	return []string{stringValue}, source, true, nil
+}
+
+// convert expands the string value of the field, validates it against the oneof tag and converts it to JSON.
+// The errors of secret fields are redacted, as they may contain the value, or the reference that provides it.
+func (i *implResolver) convert(path string, field rsf, stringValue string) (interface{}, error) {
+	stringValue, err := i.expandValue(path, field, stringValue)
+	if err != nil {
+		return nil, redactErr(i.opts, field, err)
+	}
+
+	if err := i.checkOneOfValue(field, stringValue); err != nil {
+		return nil, redactErr(i.opts, field, err)
+	}
+
+	value, err := value2Interface(field.Type, stringValue, bytesEncoding(i.opts, field))
+	return value, redactErr(i.opts, field, err)
+}
+
+// convertPositionals is the same as convert, but for positional arguments.
+func (i *implResolver) convertPositionals(path string, field rsf, stringValues []string) (interface{}, error) {
+	// Every positional argument is expanded on its own.
+	expandedValues := make([]string, 0, len(stringValues))
+	for _, stringValue := range stringValues {
+		expanded, err := i.expandValue(path, field, stringValue)
+		if err != nil {
+			return nil, redactErr(i.opts, field, err)
+		}
+		expandedValues = append(expandedValues, expanded)
+	}
+	if err := i.checkOneOf(field, expandedValues...); err != nil {
+		return nil, redactErr(i.opts, field, err)
+	}
+
+	value, err := positionals2Interface(field.Type, expandedValues, bytesEncoding(i.opts, field))
+	return value, redactErr(i.opts, field, err)
+}
+
+// expandValue decrypts the string value if it is encrypted, resolves it if it is a reference, and then
+// reads the file at the resulting path if the field has the file tag. The path is the path of the field.
+//
+// The expanded values are cached, so that the commands, the file reads and the decryptions run only once
+// per Load, even if the field is referenced by the values of other fields.
+func (i *implResolver) expandValue(path string, field rsf, stringValue string) (string, error) {
+	key := expandedKey{path: path, value: stringValue}
+	if expanded, present := i.expanded[key]; present {
+		return expanded, nil
+	}
+
+	expanded, err := i.decrypt(stringValue)
+	if err != nil {
+		return "", err
+	}
+
+	expanded, err = i.resolveReference(expanded)
+	if err != nil {
+		return "", err
+	}
+
+	expanded, err = i.readFileTag(field, expanded)
+	if err != nil {
+		return "", err
+	}
+
+	if i.expanded == nil {
+		i.expanded = map[expandedKey]string{}
+	}
+	i.expanded[key] = expanded
+	return expanded, nil
+}
+
+// readFileTag reads the file at the path given by the string value, if the field has the file tag.
+// Otherwise, the string value is returned as is.
+func (i *implResolver) readFileTag(field rsf, stringValue string) (string, error) {
+	if fromFile, _ := strconv.ParseBool(field.Tag.Get(i.opts.FileTagName)); !fromFile {
+		return stringValue, nil
+	}
+
+	content, err := readValueFile(stringValue)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the file given by the value: %w", err)
+	}
+	return content, nil
+}
+
+func (i *implResolver) resolveArg(field rsf, flagger iFlagger) (string, fieldSource, bool) {
 	tagValue, present := field.Tag.Lookup(i.opts.ArgTagName)
 	if !present || tagValue == "" {
-		return "", false
+		return "", fieldSource{}, false
 	}
 
 	// Getting only the flagName. We don't need flagDoc here.
 	flagName, _ := getFlagNameAndDoc(tagValue, ",")
-	return flagger.LookupFlag(flagName)
+	value, present := flagger.LookupFlag(flagName)
+	return value, fieldSource{kind: SourceFlag, key: flagName, raw: value}, present
 }
 
-func (i *implResolver) resolveEnv(field rsf) (string, bool) {
+func (i *implResolver) resolvePos(field rsf, flagger iFlagger) ([]string, fieldSource, bool) {
+	position, present := field.Tag.Lookup(i.opts.PosTagName)
+	if !present {
+		return nil, fieldSource{}, false
+	}
+
+	values, present := flagger.LookupPositional(position)
+	return values, fieldSource{kind: SourcePositional, key: position, raw: strings.Join(values, " ")}, present
+}
+
+// resolveEnv looks up the environment variable of the field. If it is not set, the "<ENV>_FILE"
+// environment variable is looked up instead, which holds the path of a file that contains the value,
+// as is the convention for secrets mounted by Docker and Kubernetes.
+func (i *implResolver) resolveEnv(field rsf) (string, fieldSource, bool, error) {
 	tagValue, present := field.Tag.Lookup(i.opts.EnvTagName)
 	if !present || tagValue == "" {
-		return "", false
+		return "", fieldSource{}, false, nil
+	}
+
+	if value, present := i.lookupEnv(tagValue); present {
+		return value, fieldSource{kind: SourceEnv, key: tagValue, raw: value}, true, nil
+	}
+
+	// The raw value is the path of the file, as that is what the environment provides.
+	fileEnv := tagValue + envFileSuffix
+	path, present := i.lookupEnv(fileEnv)
+	source := fieldSource{kind: SourceEnv, key: fileEnv, raw: path}
+	if !present {
+		return "", fieldSource{}, false, nil
+	}
+
+	value, err := readValueFile(path)
+	if err != nil {
+		return "", source, false, fmt.Errorf("failed to read the file given by %s: %w", fileEnv, err)
+	}
+	return value, source, true, nil
+}
+
+// resolveConfigDir looks up the file of the field in the ConfigDir. The file is named after the environment
+// variable of the field, or after the nested path of the field, like "http/port".
+func (i *implResolver) resolveConfigDir(parents []rsf, field rsf) (string, fieldSource, bool, error) {
+	if i.configDir == nil || field.Type.Kind() == reflect.Struct {
+		return "", fieldSource{}, false, nil
 	}
 
-	return os.LookupEnv(tagValue)
+	value, key, present, err := i.configDir.lookup(field.Tag.Get(i.opts.EnvTagName), configDirPathKey(parents, field))
+	return value, fieldSource{kind: SourceConfigDir, key: key, raw: value}, present, err
 }
 
-func (i *implResolver) resolveDef(field rsf) (string, bool) {
-	return field.Tag.Lookup(i.opts.DefTagName)
+func (i *implResolver) resolveDef(field rsf) (string, fieldSource, bool) {
+	value, present := field.Tag.Lookup(i.opts.DefTagName)
+	return value, fieldSource{kind: SourceDefault, raw: value}, present
 }
@@ -2,13 +2,16 @@ package confetti
 
 import (
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestImplResolver_ResolveField tests if the ResolveField method works as expected with correct inputs.
 func TestImplResolver_ResolveField(t *testing.T) {
-	instance := &implResolver{opts: defaultLoaderOptions}
+	instance := &implResolver{opts: defaultLoaderOptions, lookupEnv: os.LookupEnv}
 	flagger := &implMockFlagger{argMap: map[string]string{}, registerErr: nil}
 
 	// The expected resolved value of each field is governed by the mockers slice below.
@@ -52,7 +55,7 @@ func TestImplResolver_ResolveField(t *testing.T) {
 		expected := mockers[ind]()
 
 		// Resolving the field value.
-		resolved, err := instance.ResolveField(nil, &fieldType, flagger)
+		resolved, _, err := instance.ResolveField(nil, &fieldType, flagger)
 		if err != nil {
 			t.Errorf("Expecting no error in ResolveField, but got: %+v", err)
 			return
@@ -68,7 +71,7 @@ func TestImplResolver_ResolveField(t *testing.T) {
 
 // TestImplResolver_ResolveField_BadType tests if ResolveField returns an error upon bad value types.
 func TestImplResolver_ResolveField_BadType(t *testing.T) {
-	instance := &implResolver{opts: defaultLoaderOptions}
+	instance := &implResolver{opts: defaultLoaderOptions, lookupEnv: os.LookupEnv}
 	flagger := &implMockFlagger{argMap: map[string]string{}, registerErr: nil}
 
 	// The expected resolved value of each field is governed by the mockers slice below.
@@ -108,10 +111,239 @@ func TestImplResolver_ResolveField_BadType(t *testing.T) {
 		mockers[ind]()
 
 		// Resolving the field value.
-		resolved, err := instance.ResolveField(nil, &fieldType, flagger)
+		resolved, _, err := instance.ResolveField(nil, &fieldType, flagger)
 		if err == nil {
 			t.Errorf("expected err to occur but got resolved value: %+v", resolved)
 			return
 		}
 	}
 }
+
+// TestImplResolver_ResolveField_Positional tests if ResolveField binds positional arguments with the correct types.
+func TestImplResolver_ResolveField_Positional(t *testing.T) {
+	instance := &implResolver{opts: defaultLoaderOptions, lookupEnv: os.LookupEnv}
+	flagger := &implMockFlagger{posMap: map[string][]string{
+		"0":    {"src"},
+		"1":    {"10"},
+		"rest": {"1", "2", "3"},
+	}}
+
+	dummyTarget := struct {
+		dummyField1 string `pos:"0"`
+		dummyField2 int    `pos:"1" def:"5"`
+		dummyField3 []int  `pos:"rest"`
+	}{}
+
+	expected := []interface{}{"src", float64(10), []interface{}{float64(1), float64(2), float64(3)}}
+
+	structValue := reflect.ValueOf(dummyTarget)
+	structType := structValue.Type()
+
+	for ind := 0; ind < structValue.NumField(); ind++ {
+		fieldType := structType.Field(ind)
+
+		resolved, _, err := instance.ResolveField(nil, &fieldType, flagger)
+		if err != nil {
+			t.Errorf("Expecting no error in ResolveField, but got: %+v", err)
+			return
+		}
+		if !reflect.DeepEqual(expected[ind], resolved) {
+			t.Errorf("expected resolved value: %+v, but got: %+v", expected[ind], resolved)
+		}
+	}
+}
+
+// TestImplResolver_ResolveField_MissingPositional tests if ResolveField reports missing required positionals.
+func TestImplResolver_ResolveField_MissingPositional(t *testing.T) {
+	instance := &implResolver{opts: defaultLoaderOptions, lookupEnv: os.LookupEnv}
+	flagger := &implMockFlagger{posMap: map[string][]string{}}
+
+	dummyTarget := struct {
+		// Required, as there is no other source of value.
+		dummyField1 string `pos:"0"`
+		// Not required because of the default value.
+		dummyField2 string `pos:"1" def:"2"`
+		// The rest arguments are never required.
+		dummyField3 []string `pos:"rest"`
+	}{}
+
+	structValue := reflect.ValueOf(dummyTarget)
+	structType := structValue.Type()
+
+	for ind := 0; ind < structValue.NumField(); ind++ {
+		fieldType := structType.Field(ind)
+
+		_, _, err := instance.ResolveField(nil, &fieldType, flagger)
+		if (ind == 0) != (err != nil) {
+			t.Errorf("Unexpected error for field %s: %+v", fieldType.Name, err)
+		}
+	}
+}
+
+// TestImplResolver_ResolveField_Source tests if ResolveField reports the source that provided the value.
+func TestImplResolver_ResolveField_Source(t *testing.T) {
+	instance := &implResolver{opts: defaultLoaderOptions, lookupEnv: os.LookupEnv}
+	flagger := &implMockFlagger{argMap: map[string]string{"df-1": "a"}, posMap: map[string][]string{"rest": {"b", "c"}}}
+
+	_ = os.Setenv("CONFETTI_SOURCE_DF3", "d")
+	defer func() { _ = os.Unsetenv("CONFETTI_SOURCE_DF3") }()
+
+	dummyTarget := struct {
+		dummyField1 string   `def:"1" arg:"df-1"`
+		dummyField2 []string `pos:"rest"`
+		dummyField3 string   `def:"3" env:"CONFETTI_SOURCE_DF3"`
+		dummyField4 string   `def:"4" env:"CONFETTI_SOURCE_DF4"`
+		dummyField5 string   `env:"CONFETTI_SOURCE_DF5"`
+	}{}
+
+	expected := []fieldSource{
+		{kind: SourceFlag, key: "df-1", raw: "a"},
+		{kind: SourcePositional, key: "rest", raw: "b c"},
+		{kind: SourceEnv, key: "CONFETTI_SOURCE_DF3", raw: "d"},
+		{kind: SourceDefault, raw: "4"},
+		{kind: SourceNone},
+	}
+
+	structValue := reflect.ValueOf(dummyTarget)
+	structType := structValue.Type()
+
+	for ind := 0; ind < structValue.NumField(); ind++ {
+		fieldType := structType.Field(ind)
+
+		_, source, err := instance.ResolveField(nil, &fieldType, flagger)
+		if err != nil {
+			t.Errorf("Expecting no error in ResolveField, but got: %+v", err)
+			return
+		}
+		if source != expected[ind] {
+			t.Errorf("expected source: %+v, but got: %+v", expected[ind], source)
+		}
+	}
+}
+
+// TestImplResolver_ResolveField_EnvFile tests if ResolveField reads the value from the file given by "<ENV>_FILE".
+func TestImplResolver_ResolveField_EnvFile(t *testing.T) {
+	instance := &implResolver{opts: defaultLoaderOptions, lookupEnv: os.LookupEnv}
+	flagger := &implMockFlagger{}
+
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte("hunter2\n"), 0o600); err != nil {
+		t.Errorf("Failed to write the file: %+v", err)
+		return
+	}
+
+	_ = os.Setenv("CONFETTI_ENV_FILE_DF1_FILE", path)
+	_ = os.Setenv("CONFETTI_ENV_FILE_DF2", "direct")
+	_ = os.Setenv("CONFETTI_ENV_FILE_DF2_FILE", path)
+	_ = os.Setenv("CONFETTI_ENV_FILE_DF3_FILE", path+".missing")
+	defer func() {
+		for _, name := range []string{"DF1_FILE", "DF2", "DF2_FILE", "DF3_FILE"} {
+			_ = os.Unsetenv("CONFETTI_ENV_FILE_" + name)
+		}
+	}()
+
+	dummyTarget := struct {
+		dummyField1 string `env:"CONFETTI_ENV_FILE_DF1" def:"1"`
+		dummyField2 string `env:"CONFETTI_ENV_FILE_DF2"`
+		dummyField3 string `env:"CONFETTI_ENV_FILE_DF3" def:"3"`
+	}{}
+
+	structType := reflect.TypeOf(dummyTarget)
+
+	// The file content is trimmed.
+	field := structType.Field(0)
+	resolved, source, err := instance.ResolveField(nil, &field, flagger)
+	if err != nil || resolved != "hunter2" {
+		t.Errorf("Expected resolved value: hunter2, got: %+v, err: %+v", resolved, err)
+	}
+	if source.key != "CONFETTI_ENV_FILE_DF1_FILE" || source.raw != path {
+		t.Errorf("Unexpected source: %+v", source)
+	}
+
+	// The environment variable itself takes precedence.
+	field = structType.Field(1)
+	if resolved, _, _ := instance.ResolveField(nil, &field, flagger); resolved != "direct" {
+		t.Errorf("Expected resolved value: direct, got: %+v", resolved)
+	}
+
+	// An unreadable file is an error, even if there is a default value.
+	field = structType.Field(2)
+	if _, _, err := instance.ResolveField(nil, &field, flagger); err == nil || !strings.Contains(err.Error(), "_FILE") {
+		t.Errorf("Expected an error naming the _FILE variable, got: %+v", err)
+	}
+}
+
+// TestImplResolver_ResolveField_FileTag tests if ResolveField reads the value from the file at the given path.
+func TestImplResolver_ResolveField_FileTag(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "port")
+	if err := os.WriteFile(path, []byte(" 8080\n"), 0o600); err != nil {
+		t.Errorf("Failed to write the file: %+v", err)
+		return
+	}
+
+	instance := &implResolver{opts: defaultLoaderOptions, lookupEnv: os.LookupEnv}
+	flagger := &implMockFlagger{
+		argMap: map[string]string{"df-1": path, "df-3": filepath.Join(tempDir, "missing")},
+		posMap: map[string][]string{"rest": {path, path}},
+	}
+
+	dummyTarget := struct {
+		dummyField1 int   `arg:"df-1" file:"true"`
+		dummyField2 int   `def:"" file:"true"`
+		dummyField3 int   `arg:"df-3" file:"true"`
+		dummyField4 []int `pos:"rest" file:"true"`
+	}{}
+
+	structType := reflect.TypeOf(dummyTarget)
+
+	field := structType.Field(0)
+	resolved, source, err := instance.ResolveField(nil, &field, flagger)
+	if err != nil || resolved != float64(8080) {
+		t.Errorf("Expected resolved value: 8080, got: %+v, err: %+v", resolved, err)
+	}
+	// The raw value is the path, not the content.
+	if source.raw != path {
+		t.Errorf("Expected raw value: %s, got: %s", path, source.raw)
+	}
+
+	for _, ind := range []int{1, 2} {
+		field = structType.Field(ind)
+		if _, _, err := instance.ResolveField(nil, &field, flagger); err == nil || !strings.Contains(err.Error(), "file") {
+			t.Errorf("Expected a file error for field %s, got: %+v", field.Name, err)
+		}
+	}
+
+	field = structType.Field(3)
+	resolved, _, err = instance.ResolveField(nil, &field, flagger)
+	if err != nil || !reflect.DeepEqual(resolved, []interface{}{float64(8080), float64(8080)}) {
+		t.Errorf("Expected resolved value: [8080 8080], got: %+v, err: %+v", resolved, err)
+	}
+}
+
+// TestImplResolver_ResolveField_Duration tests if ResolveField accepts durations in both formats.
+func TestImplResolver_ResolveField_Duration(t *testing.T) {
+	instance := &implResolver{opts: defaultLoaderOptions, lookupEnv: os.LookupEnv}
+	flagger := &implMockFlagger{argMap: map[string]string{}}
+
+	dummyTarget := struct {
+		dummyField1 time.Duration `def:"1m30s"`
+		dummyField2 time.Duration `def:"1000"`
+	}{}
+
+	expected := []interface{}{int64(90 * time.Second), float64(1000)}
+
+	structType := reflect.TypeOf(dummyTarget)
+	for ind := 0; ind < structType.NumField(); ind++ {
+		fieldType := structType.Field(ind)
+
+		resolved, _, err := instance.ResolveField(nil, &fieldType, flagger)
+		if err != nil {
+			t.Errorf("Expecting no error in ResolveField, but got: %+v", err)
+			return
+		}
+		if expected[ind] != resolved {
+			t.Errorf("expected resolved value: %+v, but got: %+v", expected[ind], resolved)
+		}
+	}
+}
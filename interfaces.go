@@ -1,10 +1,17 @@
 package confetti
 
 import (
-	"flag"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
 )
 
 // ILoader represents a configuration loader.
+//
+// An ILoader can be used for any number of Load calls, including concurrent ones.
+// The Loader provided by New implements it, and it has more methods, like LoadWithReport,
+// RegisterFlags and LoadCommand. They are not part of ILoader, so that its implementations keep working.
 type ILoader interface {
 	// Load loads the configs into the provided target.
 	Load(target interface{}) error
@@ -18,13 +25,19 @@ type iFlagger interface {
 	Parse() error
 	// LookupFlag provides the value of the specified flag. The second return param tells if the value exists.
 	LookupFlag(flagName string) (flagValue string, exists bool)
+	// LookupPositional provides the positional arguments for the specified position, which is either
+	// an index or "rest". An index provides a single value. The second return param tells if the values exist.
+	LookupPositional(position string) (values []string, exists bool)
 }
 
 // iResolver manages the resolution of values.
 type iResolver interface {
-	// ResolveField resolves the value of a struct field using the various struct tags.
+	// RegisterField makes the field known to the resolver, so that other fields can refer to it.
+	// It should be called for all fields of a target before any ResolveField call for that target.
+	RegisterField(parents []rsf, field rsf) error
+	// ResolveField resolves the value of a struct field using the various struct tags, along with its source.
 	// It requires an iFlagger to get the flag values.
-	ResolveField(parents []rsf, field rsf, flagger iFlagger) (resolved interface{}, err error)
+	ResolveField(parents []rsf, field rsf, flagger iFlagger) (resolved interface{}, source fieldSource, err error)
 }
 
 // NewDefLoader provides a new ILoader instance with default settings.
@@ -34,25 +47,84 @@ func NewDefLoader() ILoader {
 
 // NewLoader provides a new ILoader instance.
 func NewLoader(opts LoaderOptions) ILoader {
+	return newLoader(opts)
+}
+
+// New provides a new Loader instance. It is the same as NewLoader, but it provides the Loader itself,
+// which has more methods than ILoader, like LoadWithReport, RegisterFlags and LoadCommand.
+// The missing options take their default values, so New(LoaderOptions{}) is the same as NewDefLoader.
+func New(opts LoaderOptions) *Loader {
+	return newLoader(opts)
+}
+
+// newLoader provides a new Loader instance with the missing options filled in.
+func newLoader(opts LoaderOptions) *Loader {
+	// Filling out missing option values.
+	opts.complete()
+	return &Loader{
+		opts: &opts,
+		// A new flagger is created for every Load call, so the loader can be reused safely.
+		flaggerProvider: func() iFlagger { return newFlagger(&opts) },
+		// A new resolver is created for every Load call as well, as it keeps a snapshot of the ConfigDir.
+		resolverProvider: func() iResolver { return newResolver(&opts, os.LookupEnv) },
+	}
+}
+
+// Describe provides the FieldInfo of every non-struct field of the target, in the order of declaration.
+// It reads the struct tags exactly as the ILoader does, so it can be used to generate documentation.
+func Describe(target interface{}, opts LoaderOptions) ([]FieldInfo, error) {
+	if !isStructPointer(target) {
+		return nil, errors.New("target must be a struct pointer")
+	}
+
 	// Filling out missing option values.
 	opts.complete()
-	return &implLoader{
-		opts:     &opts,
-		flagger:  newFlagger(&opts),
-		resolver: newResolver(&opts),
+	loader := &Loader{opts: &opts}
+
+	var infos []FieldInfo
+	action := func(parents []rsf, field rsf) error {
+		// Nested structs are not fields of their own, their fields are described instead.
+		if field.Type.Kind() != reflect.Struct {
+			infos = append(infos, newFieldInfo(&opts, parents, field))
+		}
+		return nil
+	}
+
+	// Getting the value out of the struct pointer to loop over its fields.
+	structValue := reflect.ValueOf(target).Elem().Interface()
+	if err := loader.forEachStructField(structValue, action, nil); err != nil {
+		return nil, fmt.Errorf("failed to describe fields: %w", err)
 	}
+
+	return infos, nil
 }
 
 // newFlagger returns a new iFlagger instance.
 func newFlagger(opts *LoaderOptions) iFlagger {
-	return &implFlagger{
-		opts:    opts,
-		flagSet: flag.NewFlagSet(opts.Title, flag.ContinueOnError),
-		flags:   map[string]*customFlagHolder{},
+	// If the user has provided a FlagSource, flags are managed entirely by the user.
+	if opts.FlagSource != nil {
+		return &implSourceFlagger{opts: opts, source: opts.FlagSource, positionals: newPositionalTracker()}
+	}
+
+	// If the user has provided a FlagSet, it is used as is and never parsed by confetti.
+	if opts.FlagSet != nil {
+		return &implFlagger{
+			opts:        opts,
+			flagSet:     opts.FlagSet,
+			flags:       map[string]*customFlagHolder{},
+			positionals: newPositionalTracker(),
+			external:    true,
+		}
 	}
+
+	return newArgsFlagger(opts, opts.Title, "", opts.getArgs())
 }
 
-// newResolver returns a new iResolver instance.
-func newResolver(opts *LoaderOptions) iResolver {
-	return &implResolver{opts: opts}
+// newResolver returns a new iResolver instance, which looks up the environment variables with the given function.
+func newResolver(opts *LoaderOptions, lookupEnv envLookup) iResolver {
+	instance := &implResolver{opts: opts, lookupEnv: lookupEnv, fields: map[string]fieldRef{}}
+	if opts.ConfigDir != "" {
+		instance.configDir = newDirSource(opts.ConfigDir)
+	}
+	return instance
 }
@@ -1,7 +1,10 @@
 package confetti
 
 import (
+	"os"
+	"reflect"
 	"testing"
+	"time"
 )
 
 // TestNewDefLoader tests if NewDefLoader returns a valid ILoader.
@@ -22,6 +25,43 @@ func TestNewLoader(t *testing.T) {
 	}
 }
 
+// TestNew tests if New returns a valid Loader, which can be assigned to an ILoader as well.
+func TestNew(t *testing.T) {
+	loader := New(LoaderOptions{})
+	if loader == nil {
+		t.Errorf("Expected loader to be non-nil, but it is nil.")
+		return
+	}
+
+	var _ ILoader = loader
+}
+
+// loadOnlyLoader is an ILoader implemented outside of confetti, like a mock, which has only the Load method.
+type loadOnlyLoader struct {
+	calls int
+}
+
+func (l *loadOnlyLoader) Load(target interface{}) error {
+	l.calls++
+	return nil
+}
+
+// TestILoader_LoadOnly tests if an ILoader with only the Load method still works with confetti,
+// along with the ILoader provided by NewLoader.
+func TestILoader_LoadOnly(t *testing.T) {
+	external := &loadOnlyLoader{}
+	for _, loader := range []ILoader{external, NewLoader(LoaderOptions{Args: []string{}})} {
+		holder := &Holder{}
+		if err := holder.Load(loader, &struct{}{}); err != nil {
+			t.Errorf("Expected Holder.Load error: nil, got: %+v", err)
+		}
+	}
+
+	if external.calls != 1 {
+		t.Errorf("Expected Load calls: 1, got: %d", external.calls)
+	}
+}
+
 // TestNewFlagger tests if newFlagger returns a valid iFlagger.
 func TestNewFlagger(t *testing.T) {
 	flagger := newFlagger(defaultLoaderOptions)
@@ -33,9 +73,63 @@ func TestNewFlagger(t *testing.T) {
 
 // TestNewResolver tests if newResolver returns a valid iResolver.
 func TestNewResolver(t *testing.T) {
-	resolver := newResolver(defaultLoaderOptions)
+	resolver := newResolver(defaultLoaderOptions, os.LookupEnv)
 	if resolver == nil {
 		t.Errorf("Expected resolver to be non-nil, but it is nil.")
 		return
 	}
 }
+
+// TestDescribe tests if Describe provides the correct FieldInfo for all nested fields.
+func TestDescribe(t *testing.T) {
+	dummyTarget := struct {
+		DummyField1 string `def:"1" env:"DF1" arg:"df-1,Dummy Field 1" oneof:"1 2" example:"2"`
+		DummyField2 struct {
+			DummyField21 int `env:"DF21" required:"true"`
+		} `group:"Dummy Group,Dummy Group Doc"`
+		DummyField3 struct {
+			DummyField31 []time.Duration `pos:"0"`
+		}
+	}{}
+
+	infos, err := Describe(&dummyTarget, LoaderOptions{})
+	if err != nil {
+		t.Errorf("Expected error to be nil, but got: %+v", err)
+		return
+	}
+
+	expected := []FieldInfo{
+		{
+			Path: "DummyField1", TypeName: "string", FlagName: "df-1", Doc: "Dummy Field 1", Default: "1",
+			HasDefault: true, Env: "DF1", OneOf: []string{"1", "2"}, Example: "2",
+		},
+		{
+			Path: "DummyField2.DummyField21", TypeName: "int", Env: "DF21", Required: true,
+			Group: "Dummy Group", GroupDoc: "Dummy Group Doc",
+		},
+		{
+			Path: "DummyField3.DummyField31", TypeName: "list of duration", Position: "0", Required: true,
+			Group: "DummyField3",
+		},
+	}
+
+	if len(infos) != len(expected) {
+		t.Errorf("Expected %d infos, but got: %d", len(expected), len(infos))
+		return
+	}
+
+	for ind, info := range infos {
+		// Type and Tag are not compared, they come directly from reflection.
+		info.Type, info.Tag = nil, ""
+		if !reflect.DeepEqual(info, expected[ind]) {
+			t.Errorf("Expected info: %+v, but got: %+v", expected[ind], info)
+		}
+	}
+}
+
+// TestDescribe_NotStructPointer tests if Describe gives an error when the target is not a struct pointer.
+func TestDescribe_NotStructPointer(t *testing.T) {
+	if _, err := Describe(2, LoaderOptions{}); err == nil {
+		t.Errorf("Expected error from Describe, but didn't get any.")
+	}
+}
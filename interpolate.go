@@ -0,0 +1,134 @@
+package confetti
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fallbackSeparator separates the name of a reference from its fallback value, as in "${NAME:-fallback}".
+const fallbackSeparator = ":-"
+
+// fieldRef is a registered field, which can be referenced by interpolation.
+type fieldRef struct {
+	// parents are the parents of the field.
+	parents []rsf
+	// field is the field itself.
+	field rsf
+}
+
+// interpolate expands the "${NAME}" and "${NAME:-fallback}" references of the value.
+//
+// A NAME is looked up as the path of a registered field first, and then as an environment variable.
+// The fallback is used if the NAME has no value or an empty one, and it may contain references too.
+// A NAME without a value and without a fallback expands to an empty string, like it does in a shell.
+// The stack holds the paths of the fields being resolved, so that cycles are reported instead of recursing forever.
+//
+// The second return param tells if any of the expanded references is a secret field, or was interpolated from one,
+// in which case the result must be treated as a secret too.
+func (i *implResolver) interpolate(value string, flagger iFlagger, stack []string) (string, bool, error) {
+	var builder strings.Builder
+	secret := false
+
+	for index := 0; index < len(value); index++ {
+		char := value[index]
+		if char != '$' || index+1 == len(value) {
+			builder.WriteByte(char)
+			continue
+		}
+
+		// "$$" is an escaped "$". Any other "$" that does not start a reference is kept as is.
+		if value[index+1] == '$' {
+			builder.WriteByte('$')
+			index++
+			continue
+		}
+		if value[index+1] != '{' {
+			builder.WriteByte(char)
+			continue
+		}
+
+		end := findClosingBrace(value, index+2)
+		if end < 0 {
+			return "", false, fmt.Errorf(`unterminated reference starting at offset %d`, index)
+		}
+
+		expanded, expandedSecret, err := i.expandReference(value[index+2:end], flagger, stack)
+		if err != nil {
+			return "", false, err
+		}
+		builder.WriteString(expanded)
+		secret = secret || expandedSecret
+		index = end
+	}
+
+	return builder.String(), secret, nil
+}
+
+// expandReference expands the content of a single reference, that is, the part between the braces.
+// The second return param tells if the expanded value is a secret, like the one of interpolate.
+func (i *implResolver) expandReference(reference string, flagger iFlagger, stack []string) (string, bool, error) {
+	name, fallback := reference, ""
+	hasFallback := false
+	if separator := strings.Index(reference, fallbackSeparator); separator >= 0 {
+		name, fallback, hasFallback = reference[:separator], reference[separator+len(fallbackSeparator):], true
+	}
+
+	value, present, secret, err := i.lookupReference(strings.TrimSpace(name), flagger, stack)
+	if err != nil {
+		return "", false, err
+	}
+
+	if (!present || value == "") && hasFallback {
+		return i.interpolate(fallback, flagger, stack)
+	}
+	return value, secret, nil
+}
+
+// lookupReference provides the value of the field at the given path, or of the environment variable with the given name.
+// The second return param tells if the value exists, and the third one tells if it is a secret.
+func (i *implResolver) lookupReference(name string, flagger iFlagger, stack []string) (string, bool, bool, error) {
+	ref, isField := i.fields[name]
+	if !isField {
+		value, present := i.lookupEnv(name)
+		return value, present, false, nil
+	}
+
+	if containsString(stack, name) {
+		return "", false, false, fmt.Errorf("interpolation cycle: %s -> %s", strings.Join(stack, " -> "), name)
+	}
+
+	// The stack is copied, so that the sibling references do not share the backing array.
+	nextStack := append(append([]string{}, stack...), name)
+	values, source, present, err := i.resolveRaw(ref.parents, ref.field, flagger, nextStack)
+	if err != nil || !present {
+		return "", false, false, err
+	}
+
+	// The referenced field is a secret if it is one itself, or if it was interpolated from one.
+	secret := source.secret || isSecret(i.opts, ref.field)
+
+	// The value of the referenced field may be a reference, or a path to read, as well.
+	if len(values) == 1 {
+		expanded, err := i.expandValue(name, ref.field, values[0])
+		return expanded, true, secret, redactErr(i.opts, ref.field, err)
+	}
+	return strings.Join(values, " "), true, secret, nil
+}
+
+// findClosingBrace provides the index of the brace that closes the reference starting at the given index,
+// taking the nested references into account. It returns -1 if there is none.
+func findClosingBrace(value string, start int) int {
+	depth := 0
+	for index := start; index < len(value); index++ {
+		switch value[index] {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return index
+			}
+			depth--
+		}
+	}
+	return -1
+}
@@ -0,0 +1,140 @@
+package confetti
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestImplLoader_Load_Interpolate tests if the references to fields and environment variables are expanded.
+func TestImplLoader_Load_Interpolate(t *testing.T) {
+	_ = os.Setenv("CONFETTI_INTERP_HOST", "example.com")
+	_ = os.Setenv("CONFETTI_INTERP_DSN", "postgres://${DB.User}@${CONFETTI_INTERP_HOST}")
+	defer func() {
+		_ = os.Unsetenv("CONFETTI_INTERP_HOST")
+		_ = os.Unsetenv("CONFETTI_INTERP_DSN")
+	}()
+
+	target := &struct {
+		URL  string `def:"http://${CONFETTI_INTERP_HOST}:${HTTP.Port}/${Path:-api}"`
+		HTTP struct {
+			Port int `def:"8080" arg:"port"`
+		}
+		Path     string
+		Fallback string `def:"${CONFETTI_INTERP_MISSING:-${CONFETTI_INTERP_HOST}}"`
+		Escaped  string `def:"$${CONFETTI_INTERP_HOST} costs $5"`
+		DSN      string `env:"CONFETTI_INTERP_DSN"`
+		DB       struct {
+			User string `def:"admin"`
+		}
+		Flag string `arg:"flag"`
+	}{}
+
+	opts := LoaderOptions{Interpolate: true, Args: []string{"-port", "9090", "-flag", "${CONFETTI_INTERP_HOST}"}}
+	if err := NewLoader(opts).Load(target); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+
+	expected := map[string][2]string{
+		"URL":      {"http://example.com:9090/api", target.URL},
+		"Fallback": {"example.com", target.Fallback},
+		"Escaped":  {"${CONFETTI_INTERP_HOST} costs $5", target.Escaped},
+		"DSN":      {"postgres://admin@example.com", target.DSN},
+		// The command-line values are not interpolated.
+		"Flag": {"${CONFETTI_INTERP_HOST}", target.Flag},
+	}
+	for name, values := range expected {
+		if values[0] != values[1] {
+			t.Errorf("Expected %s to be: %s, got: %s", name, values[0], values[1])
+		}
+	}
+}
+
+// TestImplLoader_Load_InterpolateDisabled tests if the references are kept as is without the Interpolate option.
+func TestImplLoader_Load_InterpolateDisabled(t *testing.T) {
+	target := &struct {
+		URL string `def:"http://${HOST}"`
+	}{}
+
+	if err := NewLoader(LoaderOptions{Args: []string{}}).Load(target); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+	if target.URL != "http://${HOST}" {
+		t.Errorf("Expected URL to be: http://${HOST}, got: %s", target.URL)
+	}
+}
+
+// TestImplLoader_Load_InterpolateCycle tests if the cycles are reported with the paths of the fields.
+func TestImplLoader_Load_InterpolateCycle(t *testing.T) {
+	target := &struct {
+		A   string `def:"${Sub.B}"`
+		Sub struct {
+			B string `def:"x-${C}"`
+		}
+		C string `def:"${A}"`
+	}{}
+
+	err := NewLoader(LoaderOptions{Interpolate: true, Args: []string{}}).Load(target)
+	if err == nil || !strings.Contains(err.Error(), "interpolation cycle: A -> Sub.B -> C -> A") {
+		t.Errorf("Expected an interpolation cycle error, got: %+v", err)
+	}
+}
+
+// TestImplLoader_Load_InterpolateUnterminated tests if an unterminated reference results in an error.
+func TestImplLoader_Load_InterpolateUnterminated(t *testing.T) {
+	target := &struct {
+		URL string `def:"http://${HOST"`
+	}{}
+
+	if err := NewLoader(LoaderOptions{Interpolate: true, Args: []string{}}).Load(target); err == nil {
+		t.Errorf("Expected error from Load, but didn't get any.")
+	}
+}
+
+// TestImplLoader_Load_InterpolateSecretError tests if the errors of a field that is interpolated from a secret field
+// are redacted, as they may contain the secret.
+func TestImplLoader_Load_InterpolateSecretError(t *testing.T) {
+	target := &struct {
+		Password string `def:"hunter2" secret:"true"`
+		Port     int    `def:"80${Password}"`
+	}{}
+
+	err := NewLoader(LoaderOptions{Interpolate: true, Args: []string{}}).Load(target)
+	if err == nil || !errors.Is(err, errRedacted) || strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("Expected a redacted error, got: %+v", err)
+	}
+}
+
+// TestImplLoader_Load_InterpolateDeeplyNested tests if a reference to a deeply nested field resolves that field,
+// and not one of its siblings, as the registered fields keep their parents.
+func TestImplLoader_Load_InterpolateDeeplyNested(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigDirFiles(t, dir, map[string]string{"a/b/c/d/x": "from-dir"})
+
+	target := &struct {
+		A struct {
+			B struct {
+				C struct {
+					D struct {
+						X string `def:"from-def"`
+					}
+					E struct {
+						Y string `def:"${A.B.C.D.X}"`
+					}
+				}
+			}
+		}
+	}{}
+
+	opts := LoaderOptions{Interpolate: true, ConfigDir: dir, Args: []string{}}
+	if err := NewLoader(opts).Load(target); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+	if target.A.B.C.E.Y != "from-dir" {
+		t.Errorf("Expected A.B.C.E.Y to be: from-dir, got: %s", target.A.B.C.E.Y)
+	}
+}
@@ -0,0 +1,120 @@
+package confetti
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// KubernetesOptions can be used to customize the generated Kubernetes manifests.
+type KubernetesOptions struct {
+	// ConfigMapName is the name of the ConfigMap that holds the non-secret env variables.
+	// If empty, the Title of the LoaderOptions is used.
+	ConfigMapName string
+	// SecretName is the name of the Secret that holds the secret env variables.
+	// If empty, the Title of the LoaderOptions is used.
+	SecretName string
+	// Namespace is the namespace of the ConfigMap. It is left out if empty.
+	Namespace string
+}
+
+// WriteKubernetesConfigMap writes a ConfigMap manifest holding all non-secret env variables of the target.
+//
+// The values are the defaults from the def tag. Fields without defaults are written as comments only, as an
+// empty variable would override the default value, and it would not even be valid for non-string fields.
+// Fields marked by the secret tag are left out, as they should be provided by a Secret instead.
+func WriteKubernetesConfigMap(output io.Writer, target interface{}, opts LoaderOptions, kubeOpts KubernetesOptions) error {
+	fields, err := Describe(target, opts)
+	if err != nil {
+		return err
+	}
+
+	// Filling out missing option values.
+	opts.complete()
+	kubeOpts.complete(&opts)
+
+	_, _ = fmt.Fprintln(output, "apiVersion: v1")
+	_, _ = fmt.Fprintln(output, "kind: ConfigMap")
+	_, _ = fmt.Fprintln(output, "metadata:")
+	_, _ = fmt.Fprintf(output, "  name: %s\n", yamlString(kubeOpts.ConfigMapName))
+	if kubeOpts.Namespace != "" {
+		_, _ = fmt.Fprintf(output, "  namespace: %s\n", yamlString(kubeOpts.Namespace))
+	}
+	_, _ = fmt.Fprintln(output, "data:")
+
+	for _, field := range fields {
+		if field.Env == "" || field.Secret {
+			continue
+		}
+		if field.Doc != "" {
+			_, _ = fmt.Fprintf(output, "  # %s\n", field.Doc)
+		}
+		if !field.HasDefault {
+			_, _ = fmt.Fprintf(output, "  # %s: \"\"\n", field.Env)
+			continue
+		}
+		_, _ = fmt.Fprintf(output, "  %s: %s\n", field.Env, yamlString(field.Default))
+	}
+
+	return nil
+}
+
+// WriteKubernetesEnv writes the "envFrom" and "env" blocks of a Deployment's container spec for the target.
+//
+// All non-secret env variables come from the ConfigMap generated by WriteKubernetesConfigMap, through envFrom.
+// Every secret env variable comes from the key of the same name in the Secret, through env.
+func WriteKubernetesEnv(output io.Writer, target interface{}, opts LoaderOptions, kubeOpts KubernetesOptions) error {
+	fields, err := Describe(target, opts)
+	if err != nil {
+		return err
+	}
+
+	// Filling out missing option values.
+	opts.complete()
+	kubeOpts.complete(&opts)
+
+	_, _ = fmt.Fprintln(output, "envFrom:")
+	_, _ = fmt.Fprintln(output, "  - configMapRef:")
+	_, _ = fmt.Fprintf(output, "      name: %s\n", yamlString(kubeOpts.ConfigMapName))
+
+	var secrets []FieldInfo
+	for _, field := range fields {
+		if field.Env != "" && field.Secret {
+			secrets = append(secrets, field)
+		}
+	}
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	_, _ = fmt.Fprintln(output, "env:")
+	for _, field := range secrets {
+		_, _ = fmt.Fprintf(output, "  - name: %s\n", field.Env)
+		_, _ = fmt.Fprintln(output, "    valueFrom:")
+		_, _ = fmt.Fprintln(output, "      secretKeyRef:")
+		_, _ = fmt.Fprintf(output, "        name: %s\n", yamlString(kubeOpts.SecretName))
+		_, _ = fmt.Fprintf(output, "        key: %s\n", field.Env)
+		// A missing key should not stop the pod from starting unless the field is required.
+		if !field.Required {
+			_, _ = fmt.Fprintln(output, "        optional: true")
+		}
+	}
+
+	return nil
+}
+
+// complete fills in any absent options using the LoaderOptions.
+func (k *KubernetesOptions) complete(opts *LoaderOptions) {
+	if k.ConfigMapName == "" {
+		k.ConfigMapName = opts.Title
+	}
+	if k.SecretName == "" {
+		k.SecretName = opts.Title
+	}
+}
+
+// yamlString quotes the value as a YAML string. JSON strings are valid double quoted YAML strings.
+func yamlString(value string) string {
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
@@ -0,0 +1,118 @@
+package confetti
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// dummyKubernetesTarget is the target used by the Kubernetes tests.
+type dummyKubernetesTarget struct {
+	LogLevel string `def:"info" env:"LOG_LEVEL" arg:"log-level,Log level"`
+	DB       struct {
+		Host     string `def:"localhost" env:"DB_HOST"`
+		Password string `env:"DB_PASSWORD" secret:"true" required:"true"`
+		Token    string `env:"DB_TOKEN" secret:"true"`
+	}
+	Timeout int    `env:"TIMEOUT"`
+	NoEnv   string `def:"1"`
+}
+
+// TestWriteKubernetesConfigMap tests if the ConfigMap holds all non-secret env variables.
+func TestWriteKubernetesConfigMap(t *testing.T) {
+	expected := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: "app-config"
+  namespace: "prod"
+data:
+  # Log level
+  LOG_LEVEL: "info"
+  DB_HOST: "localhost"
+  # TIMEOUT: ""
+`
+
+	output := &bytes.Buffer{}
+	kubeOpts := KubernetesOptions{ConfigMapName: "app-config", Namespace: "prod"}
+	if err := WriteKubernetesConfigMap(output, &dummyKubernetesTarget{}, LoaderOptions{}, kubeOpts); err != nil {
+		t.Errorf("Expected WriteKubernetesConfigMap error: nil, got: %+v", err)
+		return
+	}
+
+	if output.String() != expected {
+		t.Errorf("Expected ConfigMap:\n%s\nbut got:\n%s", expected, output.String())
+	}
+}
+
+// TestWriteKubernetesEnv tests if the secret env variables are sourced from the Secret.
+func TestWriteKubernetesEnv(t *testing.T) {
+	expected := `envFrom:
+  - configMapRef:
+      name: "app"
+env:
+  - name: DB_PASSWORD
+    valueFrom:
+      secretKeyRef:
+        name: "app"
+        key: DB_PASSWORD
+  - name: DB_TOKEN
+    valueFrom:
+      secretKeyRef:
+        name: "app"
+        key: DB_TOKEN
+        optional: true
+`
+
+	output := &bytes.Buffer{}
+	if err := WriteKubernetesEnv(output, &dummyKubernetesTarget{}, LoaderOptions{Title: "app"}, KubernetesOptions{}); err != nil {
+		t.Errorf("Expected WriteKubernetesEnv error: nil, got: %+v", err)
+		return
+	}
+
+	if output.String() != expected {
+		t.Errorf("Expected env:\n%s\nbut got:\n%s", expected, output.String())
+	}
+}
+
+// TestWriteKubernetesConfigMap_RoundTrip tests if the generated ConfigMap, set as the environment like in a pod,
+// loads back into the target with the default values.
+func TestWriteKubernetesConfigMap_RoundTrip(t *testing.T) {
+	output := &bytes.Buffer{}
+	if err := WriteKubernetesConfigMap(output, &dummyKubernetesTarget{}, LoaderOptions{}, KubernetesOptions{}); err != nil {
+		t.Errorf("Expected WriteKubernetesConfigMap error: nil, got: %+v", err)
+		return
+	}
+
+	// Every uncommented line of the data section is a variable, with a JSON quoted value.
+	data := output.String()[strings.Index(output.String(), "\ndata:\n")+len("\ndata:\n"):]
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, quoted := getFlagNameAndDoc(line, ": ")
+		var value string
+		if err := json.Unmarshal([]byte(quoted), &value); err != nil {
+			t.Errorf("Expected a quoted value, got: %s", line)
+			return
+		}
+		_ = os.Setenv(key, value)
+		defer func() { _ = os.Unsetenv(key) }()
+	}
+
+	// The secret is required, so it is provided by the Secret.
+	_ = os.Setenv("DB_PASSWORD", "secret")
+	defer func() { _ = os.Unsetenv("DB_PASSWORD") }()
+
+	target := &dummyKubernetesTarget{}
+	if err := NewLoader(LoaderOptions{Args: []string{}}).Load(target); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+	if target.LogLevel != "info" || target.DB.Host != "localhost" || target.Timeout != 0 {
+		t.Errorf("Expected the default values, got: %+v", target)
+	}
+}
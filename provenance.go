@@ -0,0 +1,276 @@
+package confetti
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// SourceKind is the kind of source that provided the value of a field.
+type SourceKind string
+
+const (
+	// SourceFlag means that the value came from a flag.
+	SourceFlag SourceKind = "flag"
+	// SourcePositional means that the value came from positional arguments.
+	SourcePositional SourceKind = "positional"
+	// SourceEnv means that the value came from an environment variable.
+	SourceEnv SourceKind = "env"
+	// SourceDotEnv means that the value came from an environment variable that was set by the .env file.
+	SourceDotEnv SourceKind = "dotenv"
+	// SourceConfigDir means that the value came from a file of the ConfigDir.
+	SourceConfigDir SourceKind = "configdir"
+	// SourceDefault means that the value came from the def tag.
+	SourceDefault SourceKind = "default"
+	// SourceNone means that no source had a value, so the field was left at its zero value.
+	SourceNone SourceKind = "none"
+)
+
+// ConfigFormat is the format in which PrintConfig writes the effective configs.
+type ConfigFormat string
+
+const (
+	// ConfigFormatText writes an aligned table of the fields, their values and their sources.
+	ConfigFormatText ConfigFormat = "text"
+	// ConfigFormatJSON writes a JSON array with an object per field.
+	ConfigFormatJSON ConfigFormat = "json"
+)
+
+// dotEnvFile is the file that is read by the UseDotEnv option.
+const dotEnvFile = ".env"
+
+// fieldSource describes the source that provided the value of a field.
+type fieldSource struct {
+	// kind is the kind of the source.
+	kind SourceKind
+	// key identifies the source within its kind, like the name of the flag or the environment variable.
+	key string
+	// raw is the string value as provided by the source. Multiple positional arguments are joined by spaces.
+	raw string
+	// secret is true if the value was interpolated from a secret field, so it holds sensitive data
+	// even if the field itself is not secret.
+	secret bool
+}
+
+// FieldReport describes how the value of a single field was resolved by a LoadWithReport call.
+type FieldReport struct {
+	// Path is the name of the field along with the names of all its parents.
+	// Example: Parent1.Parent2.MyField
+	Path string
+	// Raw is the string value as provided by the source, after interpolation but before any conversion.
+	// Multiple positional arguments are joined by spaces. It is empty if the Source is SourceNone.
+	// For values read from files, through the file tag or an "<ENV>_FILE" variable, it is the path of the file.
+	Raw string
+	// Source is the kind of source that provided the value.
+	Source SourceKind
+	// Key identifies the source within its kind. It is the name of the flag (without the dash),
+	// the position of the positional argument, the name of the environment variable (including "_FILE")
+	// or the path of the file inside the ConfigDir.
+	// It is empty for SourceDefault and SourceNone.
+	Key string
+	// Location is the file and line that provided the value, if any. Example: .env:3
+	Location string
+	// UsedDefault is true if the value came from the def tag.
+	UsedDefault bool
+	// Secret is true if the field holds sensitive data, in which case the Raw value is redacted.
+	Secret bool
+}
+
+// newFieldReport creates the FieldReport of the field at the given path using its source.
+func newFieldReport(path string, source fieldSource) FieldReport {
+	return FieldReport{
+		Path:        path,
+		Raw:         source.raw,
+		Source:      source.kind,
+		Key:         source.key,
+		UsedDefault: source.kind == SourceDefault,
+	}
+}
+
+// configEntry is the JSON form of a FieldReport, as written by ConfigFormatJSON.
+type configEntry struct {
+	Path     string     `json:"path"`
+	Value    *string    `json:"value"`
+	Source   SourceKind `json:"source"`
+	Key      string     `json:"key,omitempty"`
+	Location string     `json:"location,omitempty"`
+}
+
+// dotEnvEntry is a variable of the .env file.
+type dotEnvEntry struct {
+	// value is the value of the variable.
+	value string
+	// location is the file and line of the variable.
+	location string
+}
+
+// PrintConfig loads the target and writes its effective configs, that is, the final value of every field
+// along with the source that provided it. The source is the flag, the positional argument, the environment
+// variable (and its line in the .env file, if it came from there) or the default value.
+//
+// It is meant for debugging which of the sources won. The "-print-config" flag, added by the
+// PrintConfigFlag option, does the same from the command line.
+func PrintConfig(output io.Writer, target interface{}, opts LoaderOptions, format ConfigFormat) error {
+	if format != ConfigFormatText && format != ConfigFormatJSON {
+		return fmt.Errorf("unknown config format: %s", format)
+	}
+
+	loader := newLoader(opts)
+	reports, _, err := loader.load(target)
+	if err != nil {
+		return err
+	}
+
+	return writeConfig(output, reports, format)
+}
+
+// writeConfig writes the FieldReports in the given format.
+func writeConfig(output io.Writer, reports []FieldReport, format ConfigFormat) error {
+	if format == ConfigFormatJSON {
+		entries := make([]configEntry, 0, len(reports))
+		for _, report := range reports {
+			entry := configEntry{Path: report.Path, Source: report.Source, Key: report.Key, Location: report.Location}
+			if report.Source != SourceNone {
+				raw := report.Raw
+				entry.Value = &raw
+			}
+			entries = append(entries, entry)
+		}
+
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(entries); err != nil {
+			return fmt.Errorf("failed to encode configs: %w", err)
+		}
+		return nil
+	}
+
+	// The header is the first row, so that it gets aligned with the rest.
+	rows := [][]string{{"FIELD", "VALUE", "SOURCE"}}
+	for _, report := range reports {
+		value := notProvided
+		if report.Source != SourceNone {
+			value = report.Raw
+		}
+		rows = append(rows, []string{report.Path, value, formatSource(report)})
+	}
+	writeAlignedRows(output, rows, nil)
+	return nil
+}
+
+// formatSource creates a human-readable description of the source.
+// Examples: "flag -port", "env PORT", "dotenv PORT (.env:3)", "default".
+func formatSource(report FieldReport) string {
+	switch report.Source {
+	case SourceFlag:
+		return "flag -" + report.Key
+	case SourcePositional:
+		return fmt.Sprintf("positional <%s>", report.Key)
+	case SourceDotEnv:
+		return fmt.Sprintf("dotenv %s (%s)", report.Key, report.Location)
+	case SourceEnv:
+		return "env " + report.Key
+	case SourceConfigDir:
+		return "configdir " + report.Key
+	default:
+		return string(report.Source)
+	}
+}
+
+// attributeDotEnv marks the environment variable sources whose values came from the .env file.
+//
+// An environment variable is attributed to the .env file if the file has it with the same value,
+// as godotenv never overrides the variables that are already set. The variable, as given by lookupEnv,
+// is compared rather than the raw value of the report, as the raw value may have been interpolated.
+func attributeDotEnv(reports []FieldReport, path string, lookupEnv envLookup) {
+	entries := readDotEnvEntries(path)
+	for ind := range reports {
+		report := &reports[ind]
+		entry, exists := entries[report.Key]
+		if report.Source != SourceEnv || !exists {
+			continue
+		}
+		if value, _ := lookupEnv(report.Key); value == entry.value {
+			report.Source = SourceDotEnv
+			report.Location = entry.location
+		}
+	}
+}
+
+// redactReports redacts the raw values of the secret fields.
+func redactReports(reports []FieldReport) {
+	for ind := range reports {
+		if reports[ind].Secret && reports[ind].Source != SourceNone {
+			reports[ind].Raw = RedactedValue
+		}
+	}
+}
+
+// readDotEnvEntries reads the variables of the .env file at the given path, along with their lines.
+// Any error results in an empty map, as the .env file is optional.
+func readDotEnvEntries(path string) map[string]dotEnvEntry {
+	values, err := godotenv.Read(path)
+	if err != nil {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = file.Close() }()
+
+	// godotenv does not provide the lines, so they are found by scanning the file for the keys.
+	entries := map[string]dotEnvEntry{}
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimPrefix(strings.TrimSpace(scanner.Text()), "export ")
+		separator := strings.IndexAny(line, "=:")
+		if separator < 0 {
+			continue
+		}
+
+		key := strings.TrimSpace(line[:separator])
+		if value, exists := values[key]; exists {
+			entries[key] = dotEnvEntry{value: value, location: fmt.Sprintf("%s:%d", path, lineNum)}
+		}
+	}
+	return entries
+}
+
+// printConfigValue is the value of the print-config flag.
+// It can be given without a value, like a boolean flag, to get the text format.
+type printConfigValue struct {
+	// format is the requested format, or empty if the flag is not given.
+	format ConfigFormat
+}
+
+func (p *printConfigValue) String() string {
+	if p == nil {
+		return ""
+	}
+	return string(p.format)
+}
+
+func (p *printConfigValue) Set(value string) error {
+	switch value {
+	case "true", string(ConfigFormatText):
+		p.format = ConfigFormatText
+	case string(ConfigFormatJSON):
+		p.format = ConfigFormatJSON
+	case "false":
+		p.format = ""
+	default:
+		return errors.New("must be one of: text, json")
+	}
+	return nil
+}
+
+// IsBoolFlag allows the flag to be given without a value.
+func (p *printConfigValue) IsBoolFlag() bool { return true }
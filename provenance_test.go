@@ -0,0 +1,244 @@
+package confetti
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// dummyProvenanceTarget is the target used by the provenance tests.
+type dummyProvenanceTarget struct {
+	LogLevel string `def:"info" env:"CONFETTI_PROV_LOG_LEVEL" arg:"log-level"`
+	HTTP     struct {
+		Port int `def:"8080" env:"CONFETTI_PROV_PORT" arg:"port"`
+	}
+	Host  string `def:"localhost" env:"CONFETTI_PROV_HOST"`
+	Name  string `env:"CONFETTI_PROV_NAME"`
+	Input string `pos:"0" def:"-"`
+}
+
+// TestPrintConfig_Text tests if PrintConfig writes every field along with the source that provided it.
+func TestPrintConfig_Text(t *testing.T) {
+	_ = os.Setenv("CONFETTI_PROV_PORT", "9090")
+	defer func() { _ = os.Unsetenv("CONFETTI_PROV_PORT") }()
+
+	opts := LoaderOptions{Args: []string{"-log-level", "debug", "in.txt"}}
+	output := &bytes.Buffer{}
+	if err := PrintConfig(output, &dummyProvenanceTarget{}, opts, ConfigFormatText); err != nil {
+		t.Errorf("Expected PrintConfig error: nil, got: %+v", err)
+		return
+	}
+
+	expected := `  FIELD      VALUE      SOURCE
+  LogLevel   debug      flag -log-level
+  HTTP.Port  9090       env CONFETTI_PROV_PORT
+  Host       localhost  default
+  Name       -          none
+  Input      in.txt     positional <0>
+`
+
+	if output.String() != expected {
+		t.Errorf("Expected configs:\n%s\nbut got:\n%s", expected, output.String())
+	}
+}
+
+// TestPrintConfig_JSON tests if PrintConfig writes the JSON format with the source kind and key of every field.
+func TestPrintConfig_JSON(t *testing.T) {
+	opts := LoaderOptions{Args: []string{}}
+	output := &bytes.Buffer{}
+	if err := PrintConfig(output, &dummyProvenanceTarget{}, opts, ConfigFormatJSON); err != nil {
+		t.Errorf("Expected PrintConfig error: nil, got: %+v", err)
+		return
+	}
+
+	var entries []configEntry
+	if err := json.Unmarshal(output.Bytes(), &entries); err != nil {
+		t.Errorf("Expected valid JSON, got error: %+v", err)
+		return
+	}
+	if len(entries) != 5 {
+		t.Errorf("Expected 5 entries, got: %d", len(entries))
+		return
+	}
+
+	if entries[1].Path != "HTTP.Port" || entries[1].Source != SourceDefault || *entries[1].Value != "8080" {
+		t.Errorf("Unexpected entry of HTTP.Port: %+v", entries[1])
+	}
+	if entries[3].Source != SourceNone || entries[3].Value != nil {
+		t.Errorf("Expected entry of Name to have no value, got: %+v", entries[3])
+	}
+}
+
+// TestPrintConfig_Interpolate tests if PrintConfig writes the interpolated values, which are the final ones.
+func TestPrintConfig_Interpolate(t *testing.T) {
+	_ = os.Setenv("CONFETTI_PROV_HOST", "example.com")
+	_ = os.Setenv("CONFETTI_PROV_URL", "http://${CONFETTI_PROV_HOST}:${HTTP.Port}")
+	defer func() {
+		_ = os.Unsetenv("CONFETTI_PROV_HOST")
+		_ = os.Unsetenv("CONFETTI_PROV_URL")
+	}()
+
+	target := &struct {
+		HTTP struct {
+			Port int `def:"8080"`
+		}
+		URL    string `env:"CONFETTI_PROV_URL"`
+		Health string `def:"${URL}/health"`
+	}{}
+
+	opts := LoaderOptions{Interpolate: true, Args: []string{}}
+	output := &bytes.Buffer{}
+	if err := PrintConfig(output, target, opts, ConfigFormatText); err != nil {
+		t.Errorf("Expected PrintConfig error: nil, got: %+v", err)
+		return
+	}
+
+	expected := `  FIELD      VALUE                           SOURCE
+  HTTP.Port  8080                            default
+  URL        http://example.com:8080         env CONFETTI_PROV_URL
+  Health     http://example.com:8080/health  default
+`
+
+	if output.String() != expected {
+		t.Errorf("Expected configs:\n%s\nbut got:\n%s", expected, output.String())
+	}
+}
+
+// TestPrintConfig_InterpolateSecret tests if PrintConfig redacts the values that are interpolated from secret fields,
+// directly or through other fields.
+func TestPrintConfig_InterpolateSecret(t *testing.T) {
+	_ = os.Setenv("CONFETTI_PROV_PW", "hunter2")
+	defer func() { _ = os.Unsetenv("CONFETTI_PROV_PW") }()
+
+	target := &struct {
+		DB struct {
+			Password string `env:"CONFETTI_PROV_PW" secret:"true"`
+			DSN      string `def:"postgres://u:${DB.Password}@h/db"`
+			Pool     string `def:"${DB.DSN}?pool=10"`
+			Host     string `def:"h"`
+		}
+	}{}
+
+	opts := LoaderOptions{Interpolate: true, Args: []string{}}
+	output := &bytes.Buffer{}
+	if err := PrintConfig(output, target, opts, ConfigFormatText); err != nil {
+		t.Errorf("Expected PrintConfig error: nil, got: %+v", err)
+		return
+	}
+
+	expected := `  FIELD        VALUE   SOURCE
+  DB.Password  ******  env CONFETTI_PROV_PW
+  DB.DSN       ******  default
+  DB.Pool      ******  default
+  DB.Host      h       default
+`
+
+	if output.String() != expected {
+		t.Errorf("Expected configs:\n%s\nbut got:\n%s", expected, output.String())
+	}
+	if target.DB.Pool != "postgres://u:hunter2@h/db?pool=10" {
+		t.Errorf("Expected the interpolated value to be loaded, got: %s", target.DB.Pool)
+	}
+}
+
+// TestPrintConfig_UnknownFormat tests if PrintConfig returns an error for an unknown format.
+func TestPrintConfig_UnknownFormat(t *testing.T) {
+	if err := PrintConfig(&bytes.Buffer{}, &dummyProvenanceTarget{}, LoaderOptions{}, "yaml"); err == nil {
+		t.Errorf("Expected error from PrintConfig, but didn't get any.")
+	}
+}
+
+// TestPrintConfig_DotEnv tests if the values that came from the .env file are attributed to their lines.
+func TestPrintConfig_DotEnv(t *testing.T) {
+	workDir, _ := os.Getwd()
+	tempDir := t.TempDir()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Errorf("Failed to change the working directory: %+v", err)
+		return
+	}
+	defer func() { _ = os.Chdir(workDir) }()
+	defer func() { _ = os.Unsetenv("CONFETTI_PROV_NAME") }()
+	defer func() { _ = os.Unsetenv("CONFETTI_PROV_PORT") }()
+
+	// The port is set by the environment as well, so the .env file does not override it.
+	_ = os.Setenv("CONFETTI_PROV_PORT", "9090")
+	dotEnv := "# Comment\nCONFETTI_PROV_PORT=8081\nexport CONFETTI_PROV_NAME=dotenv-name\n"
+	if err := os.WriteFile(filepath.Join(tempDir, ".env"), []byte(dotEnv), 0o600); err != nil {
+		t.Errorf("Failed to write the .env file: %+v", err)
+		return
+	}
+
+	reports, err := New(LoaderOptions{UseDotEnv: true, Args: []string{}}).LoadWithReport(&dummyProvenanceTarget{})
+	if err != nil {
+		t.Errorf("Expected LoadWithReport error: nil, got: %+v", err)
+		return
+	}
+
+	expected := FieldReport{Path: "Name", Raw: "dotenv-name", Source: SourceDotEnv, Key: "CONFETTI_PROV_NAME", Location: ".env:3"}
+	if reports[3] != expected {
+		t.Errorf("Expected report: %+v, got: %+v", expected, reports[3])
+	}
+	if reports[1].Source != SourceEnv {
+		t.Errorf("Expected source kind: %s, got: %s", SourceEnv, reports[1].Source)
+	}
+}
+
+// TestImplLoader_LoadWithReport tests if LoadWithReport reports the raw value and the source of every field.
+func TestImplLoader_LoadWithReport(t *testing.T) {
+	_ = os.Setenv("CONFETTI_PROV_PORT", "9090")
+	defer func() { _ = os.Unsetenv("CONFETTI_PROV_PORT") }()
+
+	target := &dummyProvenanceTarget{}
+	reports, err := New(LoaderOptions{Args: []string{"-log-level=debug"}}).LoadWithReport(target)
+	if err != nil {
+		t.Errorf("Expected LoadWithReport error: nil, got: %+v", err)
+		return
+	}
+
+	expected := []FieldReport{
+		{Path: "LogLevel", Raw: "debug", Source: SourceFlag, Key: "log-level"},
+		{Path: "HTTP.Port", Raw: "9090", Source: SourceEnv, Key: "CONFETTI_PROV_PORT"},
+		{Path: "Host", Raw: "localhost", Source: SourceDefault, UsedDefault: true},
+		{Path: "Name", Source: SourceNone},
+		{Path: "Input", Raw: "-", Source: SourceDefault, UsedDefault: true},
+	}
+	if !reflect.DeepEqual(expected, reports) {
+		t.Errorf("Expected reports:\n%+v\nbut got:\n%+v", expected, reports)
+	}
+
+	// The target should be loaded as usual.
+	if target.LogLevel != "debug" || target.HTTP.Port != 9090 {
+		t.Errorf("Unexpected target: %+v", target)
+	}
+}
+
+// TestImplLoader_Load_PrintConfigFlag tests if the "-print-config" flag prints the configs and returns ErrPrintConfig.
+func TestImplLoader_Load_PrintConfigFlag(t *testing.T) {
+	output := &bytes.Buffer{}
+	opts := LoaderOptions{PrintConfigFlag: true, Output: output, Args: []string{"-print-config=json", "-port", "1"}}
+
+	err := NewLoader(opts).Load(&dummyProvenanceTarget{})
+	if !errors.Is(err, ErrPrintConfig) {
+		t.Errorf("Expected error: %+v, got: %+v", ErrPrintConfig, err)
+		return
+	}
+
+	var entries []configEntry
+	if err := json.Unmarshal(output.Bytes(), &entries); err != nil {
+		t.Errorf("Expected valid JSON, got error: %+v, output: %s", err, output.String())
+		return
+	}
+	if entries[1].Source != SourceFlag || entries[1].Key != "port" {
+		t.Errorf("Unexpected entry of HTTP.Port: %+v", entries[1])
+	}
+
+	// Without the flag, Load works as usual.
+	opts.Args = []string{}
+	if err := NewLoader(opts).Load(&dummyProvenanceTarget{}); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package confetti
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// referenceSeparator separates the scheme of a reference from the rest, as in "file:///run/secrets/db".
+const referenceSeparator = "://"
+
+// ReferenceHandler resolves the value of a reference. It receives the part of the reference after "<scheme>://".
+//
+// Handlers are registered per scheme through the ReferenceHandlers option.
+type ReferenceHandler func(reference string) (string, error)
+
+// FileReference is a ReferenceHandler that reads the file at the given path.
+// The content is trimmed of the leading and trailing white space.
+//
+// Registered as "file", it resolves "file:///run/secrets/db" to the content of "/run/secrets/db".
+func FileReference(reference string) (string, error) {
+	return readValueFile(reference)
+}
+
+// ExecReference is a ReferenceHandler that runs the given command and provides its output.
+// The command is split by white space and run directly, without a shell. The output is trimmed of the
+// leading and trailing white space.
+//
+// Registered as "exec", it resolves "exec://pass show db" to the output of "pass show db".
+func ExecReference(reference string) (string, error) {
+	args := strings.Fields(reference)
+	if len(args) == 0 {
+		return "", errors.New("the command is empty")
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%w: %s", err, message)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// resolveReference resolves the value with the handler of its scheme, if the value is a reference
+// to a registered scheme. Otherwise, the value is returned as is.
+func (i *implResolver) resolveReference(stringValue string) (string, error) {
+	separator := strings.Index(stringValue, referenceSeparator)
+	if separator <= 0 {
+		return stringValue, nil
+	}
+
+	scheme := stringValue[:separator]
+	handler, exists := i.opts.ReferenceHandlers[scheme]
+	if !exists {
+		return stringValue, nil
+	}
+
+	value, err := handler(stringValue[separator+len(referenceSeparator):])
+	if err != nil {
+		return "", fmt.Errorf(`failed to resolve reference "%s": %w`, stringValue, err)
+	}
+	return value, nil
+}
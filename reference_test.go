@@ -0,0 +1,136 @@
+package confetti
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// referenceHandlers are the handlers registered by the reference tests.
+var referenceHandlers = map[string]ReferenceHandler{"file": FileReference, "exec": ExecReference}
+
+// TestImplLoader_Load_References tests if the file and exec references are resolved, and if the values
+// with unregistered schemes are kept as is.
+func TestImplLoader_Load_References(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	if err := os.WriteFile(path, []byte("s3cr3t\n"), 0o600); err != nil {
+		t.Errorf("Expected WriteFile error: nil, got: %+v", err)
+		return
+	}
+
+	_ = os.Setenv("CONFETTI_REF_DB", "file://"+path)
+	defer func() { _ = os.Unsetenv("CONFETTI_REF_DB") }()
+
+	target := &struct {
+		DB      string `env:"CONFETTI_REF_DB"`
+		Greet   string `def:"exec://echo hello world"`
+		Website string `def:"https://example.com"`
+		Flag    string `arg:"flag"`
+	}{}
+
+	opts := LoaderOptions{ReferenceHandlers: referenceHandlers, Args: []string{"-flag", "exec://echo from flag"}}
+	reports, err := New(opts).LoadWithReport(target)
+	if err != nil {
+		t.Errorf("Expected LoadWithReport error: nil, got: %+v", err)
+		return
+	}
+
+	expected := map[string][2]string{
+		"DB":      {"s3cr3t", target.DB},
+		"Greet":   {"hello world", target.Greet},
+		"Website": {"https://example.com", target.Website},
+		"Flag":    {"from flag", target.Flag},
+	}
+	for name, values := range expected {
+		if values[0] != values[1] {
+			t.Errorf("Expected %s to be: %s, got: %s", name, values[0], values[1])
+		}
+	}
+
+	// The report holds the reference, not the resolved value.
+	if reports[0].Raw != "file://"+path {
+		t.Errorf("Expected Raw to be: %s, got: %s", "file://"+path, reports[0].Raw)
+	}
+}
+
+// TestImplLoader_Load_ReferencesDisabled tests if the references are kept as is without the handlers.
+func TestImplLoader_Load_ReferencesDisabled(t *testing.T) {
+	target := &struct {
+		Greet string `def:"exec://echo hello"`
+	}{}
+
+	if err := NewLoader(LoaderOptions{Args: []string{}}).Load(target); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+	if target.Greet != "exec://echo hello" {
+		t.Errorf("Expected Greet to be: exec://echo hello, got: %s", target.Greet)
+	}
+}
+
+// TestImplLoader_Load_ReferenceError tests if a failed reference results in an error that names it.
+func TestImplLoader_Load_ReferenceError(t *testing.T) {
+	target := &struct {
+		DB string `def:"file:///confetti/does/not/exist"`
+	}{}
+
+	err := NewLoader(LoaderOptions{ReferenceHandlers: referenceHandlers, Args: []string{}}).Load(target)
+	if err == nil || !strings.Contains(err.Error(), `failed to resolve reference "file:///confetti/does/not/exist"`) {
+		t.Errorf("Expected Load error to name the reference, got: %+v", err)
+	}
+}
+
+// TestExecReference_Failure tests if the stderr of a failed command is part of the error.
+func TestExecReference_Failure(t *testing.T) {
+	if _, err := ExecReference(""); err == nil {
+		t.Errorf("Expected ExecReference error for an empty command, got: nil")
+	}
+
+	_, err := ExecReference("ls /confetti/does/not/exist")
+	if err == nil || !strings.Contains(err.Error(), "/confetti/does/not/exist") {
+		t.Errorf("Expected ExecReference error to contain the stderr, got: %+v", err)
+	}
+}
+
+// TestImplLoader_Load_ReferenceErrorSecret tests if a failed reference of a secret field does not reveal
+// the command line or its output.
+func TestImplLoader_Load_ReferenceErrorSecret(t *testing.T) {
+	target := &struct {
+		Password string `def:"exec://ls /confetti/hunter2" secret:"true"`
+	}{}
+
+	err := NewLoader(LoaderOptions{ReferenceHandlers: referenceHandlers, Args: []string{}}).Load(target)
+	if !errors.Is(err, errRedacted) || strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("Expected Load error to be redacted, got: %+v", err)
+	}
+}
+
+// TestImplLoader_Load_ReferenceOnce tests if a reference is resolved only once per Load,
+// even if its field is referenced by the values of other fields.
+func TestImplLoader_Load_ReferenceOnce(t *testing.T) {
+	calls := 0
+	handlers := map[string]ReferenceHandler{"count": func(reference string) (string, error) {
+		calls++
+		return reference, nil
+	}}
+
+	target := &struct {
+		Password string `def:"count://hunter2"`
+		DSN      string `def:"postgres://u:${Password}@h/db"`
+		Replica  string `def:"postgres://u:${Password}@r/db"`
+	}{}
+
+	opts := LoaderOptions{Interpolate: true, ReferenceHandlers: handlers, Args: []string{}}
+	if err := NewLoader(opts).Load(target); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+	if target.DSN != "postgres://u:hunter2@h/db" || target.Replica != "postgres://u:hunter2@r/db" {
+		t.Errorf("Expected the references to be expanded, got: %+v", target)
+	}
+	if calls != 1 {
+		t.Errorf("Expected the handler to be called once, got: %d", calls)
+	}
+}
@@ -0,0 +1,204 @@
+package confetti
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// jsonSchemaDialect is the URI of the JSON Schema draft used by JSONSchema.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema generates a JSON Schema (draft 2020-12) of the target.
+//
+// The types come from reflection, the defaults from the def tag, the descriptions from the arg tag,
+// the allowed values from the oneof tag and the examples from the example tag. Required fields are
+// listed under the "required" keyword of their parent object.
+func JSONSchema(target interface{}, opts LoaderOptions) (map[string]interface{}, error) {
+	if !isStructPointer(target) {
+		return nil, errors.New("target must be a struct pointer")
+	}
+
+	// Filling out missing option values.
+	opts.complete()
+	loader := &Loader{opts: &opts}
+
+	root := msi{"$schema": jsonSchemaDialect, "title": opts.Title, "type": "object", "properties": msi{}}
+
+	action := func(parents []rsf, field rsf) error {
+		// Finding the schema of the parent object, which has been created already as parents come first.
+		parentSchema := root
+		for _, parent := range parents {
+			nextSchema, exists := parentSchema["properties"].(msi)[jsonFieldName(parent)].(msi)
+			// The parent does not exist if it was left out of the schema.
+			if !exists {
+				return nil
+			}
+			parentSchema = nextSchema
+		}
+
+		// Unexported fields and the ones ignored by encoding/json are never loaded.
+		name := jsonFieldName(field)
+		if name == "-" || field.PkgPath != "" {
+			return nil
+		}
+
+		fieldSchema, err := newFieldSchema(&opts, parents, field)
+		if err != nil {
+			return err
+		}
+
+		parentSchema["properties"].(msi)[name] = fieldSchema
+		if isRequired(&opts, field) {
+			required, _ := parentSchema["required"].([]string)
+			parentSchema["required"] = append(required, name)
+		}
+		return nil
+	}
+
+	// Getting the value out of the struct pointer to loop over its fields.
+	structValue := reflect.ValueOf(target).Elem().Interface()
+	if err := loader.forEachStructField(structValue, action, nil); err != nil {
+		return nil, fmt.Errorf("failed to generate schema: %w", err)
+	}
+
+	return root, nil
+}
+
+// WriteJSONSchema writes the JSON Schema of the target as indented JSON. See JSONSchema for details.
+func WriteJSONSchema(output io.Writer, target interface{}, opts LoaderOptions) error {
+	schema, err := JSONSchema(target, opts)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+	return nil
+}
+
+// newFieldSchema creates the schema of a single field using its type and struct tags.
+func newFieldSchema(opts *LoaderOptions, parents []rsf, field rsf) (msi, error) {
+	schema := typeSchema(field.Type)
+	// Nested structs get their properties from the fields that follow.
+	if field.Type.Kind() == reflect.Struct {
+		return schema, nil
+	}
+
+	info := newFieldInfo(opts, parents, field)
+	if info.Encoding != "" {
+		setContentEncoding(schema, info.Encoding)
+	}
+	if info.Doc != "" {
+		schema["description"] = info.Doc
+	}
+
+	// The default values of secret fields are left out, as the schema is usually published.
+	if info.HasDefault && !info.Secret {
+		value, err := schemaValue(field.Type, info.Default)
+		if err != nil {
+			return nil, fmt.Errorf(`invalid default value of field "%s": %w`, info.Path, err)
+		}
+		schema["default"] = value
+	}
+
+	if len(info.OneOf) > 0 {
+		// The allowed values of list fields are the allowed values of their elements.
+		enumSchema, enumType := schema, field.Type
+		if isListType(field.Type) {
+			enumSchema, enumType = schema["items"].(msi), field.Type.Elem()
+		}
+
+		enum := make([]interface{}, 0, len(info.OneOf))
+		for _, allowed := range info.OneOf {
+			value, err := schemaValue(enumType, allowed)
+			if err != nil {
+				return nil, fmt.Errorf(`invalid oneof value of field "%s": %w`, info.Path, err)
+			}
+			enum = append(enum, value)
+		}
+		enumSchema["enum"] = enum
+	}
+
+	if info.Example != "" {
+		value, err := schemaValue(field.Type, info.Example)
+		if err != nil {
+			// Examples are informational, so an unparsable one is kept as is.
+			value = info.Example
+		}
+		schema["examples"] = []interface{}{value}
+	}
+
+	return schema, nil
+}
+
+// typeSchema creates the schema keywords that describe the given type.
+func typeSchema(fieldType reflect.Type) msi {
+	switch {
+	case fieldType == durationType:
+		// Durations are accepted in the time.ParseDuration format, as well as plain nanoseconds.
+		return msi{"type": []string{"string", "integer"}}
+	case isBytesType(fieldType):
+		return msi{"type": "string", "contentEncoding": EncodingBase64}
+	}
+
+	switch fieldType.Kind() {
+	case reflect.String:
+		return msi{"type": "string"}
+	case reflect.Bool:
+		return msi{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return msi{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return msi{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return msi{"type": "array", "items": typeSchema(fieldType.Elem())}
+	case reflect.Map:
+		return msi{"type": "object", "additionalProperties": typeSchema(fieldType.Elem())}
+	case reflect.Struct:
+		return msi{"type": "object", "properties": msi{}}
+	case reflect.Ptr:
+		return typeSchema(fieldType.Elem())
+	default:
+		// Anything is allowed for interfaces and the other kinds.
+		return msi{}
+	}
+}
+
+// setContentEncoding sets the contentEncoding keyword of a byte slice schema as per the encoding tag.
+// Raw bytes are plain strings, so they have no contentEncoding.
+func setContentEncoding(schema msi, encoding string) {
+	switch encoding {
+	case EncodingHex:
+		schema["contentEncoding"] = "base16"
+	case EncodingRaw:
+		delete(schema, "contentEncoding")
+	default:
+		schema["contentEncoding"] = encoding
+	}
+}
+
+// schemaValue converts a tag value into its JSON form for the schema.
+// Durations and byte slices are kept as strings, as that is how they are written.
+func schemaValue(fieldType reflect.Type, value string) (interface{}, error) {
+	if fieldType == durationType || isBytesType(fieldType) {
+		return value, nil
+	}
+	return value2Interface(fieldType, value, "")
+}
+
+// jsonFieldName provides the name of the field as used by encoding/json, which loads the target.
+func jsonFieldName(field rsf) string {
+	name, _ := getFlagNameAndDoc(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return strings.TrimSpace(name)
+}
@@ -0,0 +1,98 @@
+package confetti
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestJSONSchema tests if JSONSchema maps the types and tags to the correct schema keywords.
+func TestJSONSchema(t *testing.T) {
+	dummyTarget := struct {
+		LogLevel string        `def:"info" arg:"log-level,Log level" oneof:"debug info"`
+		Timeout  time.Duration `def:"5s" example:"1m"`
+		Origins  []string      `json:"origins"`
+		HTTP     struct {
+			Port int `def:"8080" required:"true"`
+		}
+		Ignored  string `json:"-"`
+		internal string
+	}{}
+
+	schema, err := JSONSchema(&dummyTarget, LoaderOptions{Title: "dummy"})
+	if err != nil {
+		t.Errorf("Expected JSONSchema error: nil, got: %+v", err)
+		return
+	}
+
+	expected := msi{
+		"$schema": jsonSchemaDialect,
+		"title":   "dummy",
+		"type":    "object",
+		"properties": msi{
+			"LogLevel": msi{"type": "string", "description": "Log level", "default": "info", "enum": []interface{}{"debug", "info"}},
+			"Timeout":  msi{"type": []string{"string", "integer"}, "default": "5s", "examples": []interface{}{"1m"}},
+			"origins":  msi{"type": "array", "items": msi{"type": "string"}},
+			"HTTP": msi{
+				"type":       "object",
+				"properties": msi{"Port": msi{"type": "integer", "default": float64(8080)}},
+				"required":   []string{"Port"},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(schema, expected) {
+		actualJSON, _ := json.Marshal(schema)
+		expectedJSON, _ := json.Marshal(expected)
+		t.Errorf("Expected schema:\n%s\nbut got:\n%s", expectedJSON, actualJSON)
+	}
+}
+
+// TestJSONSchema_BadDefault tests if JSONSchema gives an error for default values that do not match the type.
+func TestJSONSchema_BadDefault(t *testing.T) {
+	dummyTarget := struct {
+		Port int `def:"{{"`
+	}{}
+
+	if _, err := JSONSchema(&dummyTarget, LoaderOptions{}); err == nil {
+		t.Errorf("Expected error from JSONSchema, but didn't get any.")
+	}
+}
+
+// TestJSONSchema_OneOfList tests if the allowed values of list fields become the enum of their items.
+func TestJSONSchema_OneOfList(t *testing.T) {
+	dummyTarget := struct {
+		Ports []int `oneof:"80 443"`
+	}{}
+
+	schema, err := JSONSchema(&dummyTarget, LoaderOptions{})
+	if err != nil {
+		t.Errorf("Expected JSONSchema error: nil, got: %+v", err)
+		return
+	}
+
+	expected := msi{"Ports": msi{"type": "array", "items": msi{"type": "integer", "enum": []interface{}{float64(80), float64(443)}}}}
+	if !reflect.DeepEqual(schema["properties"], expected) {
+		t.Errorf("Expected properties to be: %+v, got: %+v", expected, schema["properties"])
+	}
+}
+
+// TestWriteJSONSchema tests if WriteJSONSchema writes valid JSON.
+func TestWriteJSONSchema(t *testing.T) {
+	output := &bytes.Buffer{}
+	if err := WriteJSONSchema(output, &dummyDocsTarget{}, LoaderOptions{}); err != nil {
+		t.Errorf("Expected WriteJSONSchema error: nil, got: %+v", err)
+		return
+	}
+
+	decoded := msi{}
+	if err := json.Unmarshal(output.Bytes(), &decoded); err != nil {
+		t.Errorf("Expected the output to be valid JSON, but got error: %+v", err)
+		return
+	}
+	if decoded["$schema"] != jsonSchemaDialect {
+		t.Errorf("Expected $schema to be: %s, got: %+v", jsonSchemaDialect, decoded["$schema"])
+	}
+}
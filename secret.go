@@ -0,0 +1,116 @@
+package confetti
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// RedactedValue is shown in place of the values of secret fields, in the documentation, reports and errors.
+// Integrations that show the values of confetti targets should use it as well.
+const RedactedValue = "******"
+
+// errRedacted replaces the errors of secret fields, as they may contain the value.
+var errRedacted = errors.New("invalid value: details are redacted as the field is secret")
+
+// secretType is the reflect.Type of Secret.
+var secretType = reflect.TypeOf(Secret(""))
+
+// Secret is a string that never reveals its value when printed, logged or marshalled.
+// Its String, GoString and MarshalJSON methods provide a masked value, and Value provides the actual one.
+//
+// Fields of type Secret are treated as if they had the `secret:"true"` tag, so confetti redacts them as well.
+type Secret string
+
+// Value provides the actual value of the Secret.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String provides the masked value, so that the Secret is redacted by fmt.
+func (s Secret) String() string {
+	return RedactedValue
+}
+
+// GoString provides the masked value, so that the Secret is redacted by the %#v verb as well.
+func (s Secret) GoString() string {
+	return strconv.Quote(RedactedValue)
+}
+
+// MarshalJSON provides the masked value, so that the Secret is redacted in JSON dumps.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(RedactedValue)), nil
+}
+
+// isSecret returns true if the field holds sensitive data, that is, if the secret tag is "true"
+// or if the field is of type Secret.
+func isSecret(opts *LoaderOptions, field rsf) bool {
+	if secret, _ := strconv.ParseBool(field.Tag.Get(opts.SecretTagName)); secret {
+		return true
+	}
+	return field.Type == secretType
+}
+
+// Redacted provides the values of the target (a struct pointer) as nested maps keyed by the field names,
+// with the values of the secret fields replaced by RedactedValue. The nested structs are nested maps.
+//
+// Only the Secret type masks itself, so Redacted is the way to dump the configs that have the secret tag,
+// as in fmt.Printf("%+v", redacted) or json.Marshal(redacted). It is driven by the tags only, so a field
+// whose value is interpolated from a secret field must have the secret tag as well.
+func Redacted(target interface{}, opts LoaderOptions) (map[string]interface{}, error) {
+	if !isStructPointer(target) {
+		return nil, errors.New("target must be a struct pointer")
+	}
+
+	// Filling out missing option values.
+	opts.complete()
+	loader := &Loader{opts: &opts}
+	structValue := reflect.ValueOf(target).Elem()
+
+	redacted := msi{}
+	action := func(parents []rsf, field rsf) error {
+		// Unexported fields are never loaded, so they are left out.
+		if field.PkgPath != "" {
+			return nil
+		}
+
+		nestedMap := redacted
+		for _, parent := range parents {
+			nestedMap = nestedMap[parent.Name].(msi)
+		}
+
+		fieldValue := nestedFieldValue(structValue, parents, field)
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			nestedMap[field.Name] = msi{}
+		case isSecret(&opts, field) && !fieldValue.IsZero():
+			nestedMap[field.Name] = RedactedValue
+		default:
+			nestedMap[field.Name] = fieldValue.Interface()
+		}
+		return nil
+	}
+
+	if err := loader.forEachStructField(structValue.Interface(), action, nil); err != nil {
+		return nil, fmt.Errorf("failed to redact fields: %w", err)
+	}
+	return redacted, nil
+}
+
+// redactErr replaces the error with errRedacted if the field is secret.
+func redactErr(opts *LoaderOptions, field rsf, err error) error {
+	if err == nil || !isSecret(opts, field) {
+		return err
+	}
+	return errRedacted
+}
+
+// displayDefault provides the default value of the field as shown in the documentation.
+// The default values of secret fields are redacted.
+func displayDefault(field FieldInfo) string {
+	if field.Secret && field.Default != "" {
+		return RedactedValue
+	}
+	return field.Default
+}
@@ -0,0 +1,167 @@
+package confetti
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// dummySecretTarget is the target used by the secret tests.
+type dummySecretTarget struct {
+	User     string `def:"admin" env:"CONFETTI_SECRET_USER" arg:"user"`
+	Password string `def:"hunter2" env:"CONFETTI_SECRET_PASSWORD" arg:"password" secret:"true"`
+	Token    Secret `env:"CONFETTI_SECRET_TOKEN" arg:"token"`
+}
+
+// TestSecret tests if the Secret type masks its value in fmt and JSON output.
+func TestSecret(t *testing.T) {
+	target := dummySecretTarget{Token: "s3cr3t"}
+
+	outputs := []string{
+		fmt.Sprint(target.Token),
+		fmt.Sprintf("%v", target),
+		fmt.Sprintf("%+v", target),
+		fmt.Sprintf("%#v", target),
+	}
+	marshalled, _ := json.Marshal(target)
+	outputs = append(outputs, string(marshalled))
+
+	for _, output := range outputs {
+		if strings.Contains(output, "s3cr3t") {
+			t.Errorf("Expected the secret to be masked, got: %s", output)
+		}
+	}
+
+	if target.Token.Value() != "s3cr3t" {
+		t.Errorf("Expected the value: s3cr3t, got: %s", target.Token.Value())
+	}
+}
+
+// TestImplLoader_Load_Secret tests if the Secret type is loaded like a string, and its value is reported redacted.
+func TestImplLoader_Load_Secret(t *testing.T) {
+	_ = os.Setenv("CONFETTI_SECRET_TOKEN", "s3cr3t")
+	defer func() { _ = os.Unsetenv("CONFETTI_SECRET_TOKEN") }()
+
+	target := &dummySecretTarget{}
+	reports, err := New(LoaderOptions{Args: []string{}}).LoadWithReport(target)
+	if err != nil {
+		t.Errorf("Expected LoadWithReport error: nil, got: %+v", err)
+		return
+	}
+
+	if target.Token.Value() != "s3cr3t" || target.Password != "hunter2" {
+		t.Errorf("Unexpected target values: %s, %s", target.Token.Value(), target.Password)
+	}
+
+	expected := []FieldReport{
+		{Path: "User", Raw: "admin", Source: SourceDefault, UsedDefault: true},
+		{Path: "Password", Raw: RedactedValue, Source: SourceDefault, UsedDefault: true, Secret: true},
+		{Path: "Token", Raw: RedactedValue, Source: SourceEnv, Key: "CONFETTI_SECRET_TOKEN", Secret: true},
+	}
+	if !reflect.DeepEqual(expected, reports) {
+		t.Errorf("Expected reports:\n%+v\nbut got:\n%+v", expected, reports)
+	}
+}
+
+// TestImplResolver_ResolveField_SecretErr tests if the errors of secret fields do not contain their values.
+func TestImplResolver_ResolveField_SecretErr(t *testing.T) {
+	instance := &implResolver{opts: defaultLoaderOptions, lookupEnv: os.LookupEnv}
+	flagger := &implMockFlagger{argMap: map[string]string{"df-1": "hunter2", "df-2": "hunter2", "df-3": "hunter2"}}
+
+	dummyTarget := struct {
+		dummyField1 int      `arg:"df-1" secret:"true"`
+		dummyField2 []string `arg:"df-2" secret:"true"`
+		dummyField3 Secret   `arg:"df-3" file:"true"`
+	}{}
+
+	structValue := reflect.ValueOf(dummyTarget)
+	structType := structValue.Type()
+
+	for ind := 0; ind < structValue.NumField(); ind++ {
+		fieldType := structType.Field(ind)
+
+		_, _, err := instance.ResolveField(nil, &fieldType, flagger)
+		if err == nil {
+			t.Errorf("Expected error for field %s, but didn't get any.", fieldType.Name)
+			continue
+		}
+		if strings.Contains(err.Error(), "hunter2") {
+			t.Errorf("Expected the error to be redacted, got: %+v", err)
+		}
+	}
+}
+
+// TestDescribe_SecretDefaults tests if the default values of secret fields are redacted in all documentation.
+func TestDescribe_SecretDefaults(t *testing.T) {
+	fields, err := Describe(&dummySecretTarget{}, LoaderOptions{})
+	if err != nil {
+		t.Errorf("Expected Describe error: nil, got: %+v", err)
+		return
+	}
+	if !fields[1].Secret || !fields[2].Secret {
+		t.Errorf("Expected the Password and Token fields to be secret.")
+	}
+
+	output := &bytes.Buffer{}
+	DefaultUsage(output, UsageInfo{Title: "test", Fields: fields})
+	for _, format := range []DocFormat{DocFormatMarkdown, DocFormatMan, DocFormatText, DocFormatDotEnv} {
+		_ = WriteDocs(output, &dummySecretTarget{}, LoaderOptions{}, format)
+	}
+	_ = WriteJSONSchema(output, &dummySecretTarget{}, LoaderOptions{})
+
+	if strings.Contains(output.String(), "hunter2") {
+		t.Errorf("Expected the secret default to be redacted, got:\n%s", output.String())
+	}
+	if !strings.Contains(output.String(), "admin") {
+		t.Errorf("Expected the non-secret default to be present, got:\n%s", output.String())
+	}
+}
+
+// TestRedacted tests if Redacted masks the secret fields, including the ones with the secret tag,
+// so that the result can be dumped safely.
+func TestRedacted(t *testing.T) {
+	target := &struct {
+		User     string
+		Password string `secret:"true"`
+		Token    Secret
+		Empty    string `secret:"true"`
+		DB       struct {
+			Host string
+			DSN  string `secret:"true"`
+		}
+		internal string
+	}{User: "admin", Password: "hunter2", Token: "s3cr3t", internal: "k3y"}
+	target.DB.Host, target.DB.DSN = "db", "postgres://u:hunter2@db"
+
+	redacted, err := Redacted(target, LoaderOptions{})
+	if err != nil {
+		t.Errorf("Expected Redacted error: nil, got: %+v", err)
+		return
+	}
+
+	expected := map[string]interface{}{
+		"User":     "admin",
+		"Password": RedactedValue,
+		"Token":    RedactedValue,
+		"Empty":    "",
+		"DB":       map[string]interface{}{"Host": "db", "DSN": RedactedValue},
+	}
+	if !reflect.DeepEqual(redacted, expected) {
+		t.Errorf("Expected redacted: %+v, got: %+v", expected, redacted)
+	}
+
+	marshalled, _ := json.Marshal(redacted)
+	for _, output := range []string{fmt.Sprintf("%+v", redacted), string(marshalled)} {
+		if strings.Contains(output, "hunter2") || strings.Contains(output, "s3cr3t") {
+			t.Errorf("Expected the secrets to be masked, got: %s", output)
+		}
+	}
+
+	if _, err := Redacted(*target, LoaderOptions{}); err == nil {
+		t.Errorf("Expected Redacted error for a non-pointer, got: nil")
+	}
+}
@@ -1,7 +1,13 @@
 package confetti
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
 	"reflect"
+	"strconv"
 )
 
 // rsf is a type alias for *reflect.StructField
@@ -14,13 +20,39 @@ type msi = map[string]interface{}
 // It handles nested fields well because it receives all the parents of the field as well.
 type structFieldAction func(parents []rsf, field rsf) error
 
+// ErrHelp is returned by the ILoader when the "-h" or "-help" flag is provided.
+// It is the same as flag.ErrHelp.
+var ErrHelp = flag.ErrHelp
+
+// ErrVersion is returned by the ILoader when the "-version" flag is provided.
+var ErrVersion = errors.New("version requested")
+
+// ErrPrintConfig is returned by the ILoader when the "-print-config" flag is provided.
+var ErrPrintConfig = errors.New("config printing requested")
+
+// ErrRestartRequired is returned by a Watcher reload that changes static fields, with the StaticPolicyReject policy.
+var ErrRestartRequired = errors.New("restart required")
+
+// osExit is used to exit the program. It is a variable so that it can be mocked in tests.
+var osExit = os.Exit
+
 // defaultLoaderOptions are used when the user does not provide any.
 var defaultLoaderOptions = &LoaderOptions{
-	Title:      "configs",
-	DefTagName: "def",
-	EnvTagName: "env",
-	ArgTagName: "arg",
-	UseDotEnv:  false,
+	Title:           "configs",
+	DefTagName:      "def",
+	EnvTagName:      "env",
+	ArgTagName:      "arg",
+	PosTagName:      "pos",
+	GroupTagName:    "group",
+	RequiredTagName: "required",
+	OneOfTagName:    "oneof",
+	ExampleTagName:  "example",
+	SecretTagName:   "secret",
+	PathTagName:     "path",
+	FileTagName:     "file",
+	EncodingTagName: "encoding",
+	ReloadTagName:   "reload",
+	UseDotEnv:       false,
 }
 
 // LoaderOptions can be used to customize the ILoader.
@@ -33,8 +65,68 @@ type LoaderOptions struct {
 	EnvTagName string
 	// ArgTagName can be used to alter the name of the arg tag.
 	ArgTagName string
+	// PosTagName can be used to alter the name of the pos tag.
+	PosTagName string
+	// GroupTagName can be used to alter the name of the group tag.
+	GroupTagName string
+	// RequiredTagName can be used to alter the name of the required tag.
+	RequiredTagName string
+	// OneOfTagName can be used to alter the name of the oneof tag.
+	OneOfTagName string
+	// ExampleTagName can be used to alter the name of the example tag.
+	ExampleTagName string
+	// SecretTagName can be used to alter the name of the secret tag.
+	SecretTagName string
+	// PathTagName can be used to alter the name of the path tag.
+	PathTagName string
+	// FileTagName can be used to alter the name of the file tag.
+	FileTagName string
+	// EncodingTagName can be used to alter the name of the encoding tag.
+	EncodingTagName string
+	// ReloadTagName can be used to alter the name of the reload tag.
+	ReloadTagName string
 	// UseDotEnv controls whether to read data from the .env file.
 	UseDotEnv bool
+	// ConfigDir, if provided, is a directory with a file per value, like a ConfigMap mounted by Kubernetes.
+	// A file is named after the environment variable of its field, or after the nested path of its field,
+	// like "http/port" for the field HTTP.Port. The values of the environment variables take precedence.
+	ConfigDir string
+	// Interpolate enables the expansion of "${NAME}" and "${NAME:-fallback}" references in the values of
+	// environment variables, config dir files and def tags. A NAME is either the path of another field,
+	// like HTTP.Port, or the name of an environment variable. "$$" is a literal "$".
+	Interpolate bool
+	// ReferenceHandlers resolve the values that are references, like "file:///run/secrets/db", by their schemes.
+	// A value is a reference only if its scheme has a handler here. FileReference and ExecReference can be
+	// registered as the "file" and "exec" handlers. No handlers are registered by default.
+	ReferenceHandlers map[string]ReferenceHandler
+	// DecryptionKey provides the key that decrypts the "ENC[...]" values of all sources, as created by EncryptValue.
+	// The key is requested only if there is an encrypted value. If it is nil, an encrypted value results in an error.
+	DecryptionKey KeyProvider
+	// FlagSource, if provided, is used to look up flag values instead of a flagSet.
+	// Confetti neither registers nor parses any flags in this case.
+	//
+	// It allows other flag parsers (like spf13/pflag) to feed values into confetti.
+	FlagSource FlagSource
+	// FlagSet, if provided, is used to register the flags instead of a private flagSet.
+	//
+	// Confetti never parses this FlagSet. The caller should call Loader.RegisterFlags,
+	// then parse the FlagSet, and then call Loader.Load.
+	FlagSet *flag.FlagSet
+	// Args are the command-line arguments to be parsed, without the program name.
+	// If nil, os.Args[1:] are used.
+	Args []string
+	// Output is where the help documentation and the version are written. If nil, os.Stderr is used.
+	Output io.Writer
+	// Usage, if provided, replaces the default help documentation that shows up on "-h" or "-help".
+	Usage func(output io.Writer, info UsageInfo)
+	// Version, if provided, is printed when the "-version" flag is given.
+	Version string
+	// PrintConfigFlag adds the "-print-config" flag, which prints the effective configs along with their
+	// sources, as PrintConfig does. The flag takes an optional format, as in "-print-config=json".
+	PrintConfigFlag bool
+	// ExitOnHelp makes the program print the help documentation, the version or the effective configs
+	// and then exit with code 0, instead of returning ErrHelp, ErrVersion or ErrPrintConfig.
+	ExitOnHelp bool
 }
 
 // complete checks all fields in the struct and fills in any absent ones using the default options.
@@ -51,6 +143,126 @@ func (l *LoaderOptions) complete() {
 	if l.ArgTagName == "" {
 		l.ArgTagName = defaultLoaderOptions.ArgTagName
 	}
+	if l.PosTagName == "" {
+		l.PosTagName = defaultLoaderOptions.PosTagName
+	}
+	if l.GroupTagName == "" {
+		l.GroupTagName = defaultLoaderOptions.GroupTagName
+	}
+	if l.RequiredTagName == "" {
+		l.RequiredTagName = defaultLoaderOptions.RequiredTagName
+	}
+	if l.OneOfTagName == "" {
+		l.OneOfTagName = defaultLoaderOptions.OneOfTagName
+	}
+	if l.ExampleTagName == "" {
+		l.ExampleTagName = defaultLoaderOptions.ExampleTagName
+	}
+	if l.SecretTagName == "" {
+		l.SecretTagName = defaultLoaderOptions.SecretTagName
+	}
+	if l.PathTagName == "" {
+		l.PathTagName = defaultLoaderOptions.PathTagName
+	}
+	if l.FileTagName == "" {
+		l.FileTagName = defaultLoaderOptions.FileTagName
+	}
+	if l.EncodingTagName == "" {
+		l.EncodingTagName = defaultLoaderOptions.EncodingTagName
+	}
+	if l.ReloadTagName == "" {
+		l.ReloadTagName = defaultLoaderOptions.ReloadTagName
+	}
+}
+
+// getArgs provides the command-line arguments to be parsed.
+func (l *LoaderOptions) getArgs() []string {
+	if l.Args != nil {
+		return l.Args
+	}
+	return os.Args[1:]
+}
+
+// Command represents a subcommand of the program, selected by the first positional argument.
+type Command struct {
+	// Name is the value of the first positional argument that selects this command.
+	Name string
+	// Doc is the usage info of the command that will show up on "-h".
+	Doc string
+	// Target is the struct pointer that the configs of this command are loaded into.
+	Target interface{}
+}
+
+// FlagSource provides flag values from a flag parser that is managed outside of confetti.
+//
+// If the FlagSource also has an "Args() []string" method, like flag.FlagSet and pflag.FlagSet do,
+// it is used to provide the positional arguments.
+type FlagSource interface {
+	// LookupFlag provides the value of the specified flag. The second return param tells if the flag was set.
+	LookupFlag(flagName string) (flagValue string, exists bool)
+}
+
+// FieldInfo describes a single field of a confetti target, as seen through its struct tags.
+type FieldInfo struct {
+	// Path is the name of the field along with the names of all its parents.
+	// Example: Parent1.Parent2.MyField
+	Path string
+	// Type is the Go type of the field.
+	Type reflect.Type
+	// TypeName is a user-friendly name of the Type, like "int", "duration" or "list of string".
+	TypeName string
+	// Tag is the complete struct tag of the field. It allows reading tags that confetti does not know about.
+	Tag reflect.StructTag
+	// FlagName is the name of the flag, taken from the arg tag. It is empty if the field has no flag.
+	FlagName string
+	// Doc is the usage info of the field, taken from the arg tag.
+	Doc string
+	// Default is the default value, taken from the def tag.
+	Default string
+	// HasDefault is true if the def tag is present, even if it is empty.
+	HasDefault bool
+	// Env is the name of the environment variable, taken from the env tag.
+	Env string
+	// Position is the position of the positional argument, taken from the pos tag.
+	Position string
+	// Required is true if the field must have a value, either due to the required tag,
+	// or because it is an indexed positional argument with no default value.
+	Required bool
+	// OneOf are the allowed values of the field, taken from the space separated oneof tag.
+	OneOf []string
+	// Example is an example value of the field, taken from the example tag.
+	Example string
+	// Secret is true if the field holds sensitive data, like a password, as marked by the secret tag
+	// or by the Secret type. The values of secret fields are redacted in the documentation and the reports.
+	Secret bool
+	// File is true if the value of the field is the path of a file that holds the actual value,
+	// as marked by the file tag.
+	File bool
+	// PathKind is "file" or "dir" if the field holds a path of that kind, as marked by the path tag.
+	// It is "file" for the fields with the file tag as well.
+	// It is used for the file path completion of shell completion scripts.
+	PathKind string
+	// Encoding is the encoding of a byte slice field, taken from the encoding tag. It is "base64" if the tag
+	// is absent, and empty for the fields of other types.
+	Encoding string
+	// Group is the heading of the section that the field belongs to in the help documentation.
+	// It is taken from the group tag of the parent struct field, or it is the path of the parent.
+	// It is empty for fields that have no parent.
+	Group string
+	// GroupDoc is the description of the Group, taken from the group tag of the parent struct field.
+	GroupDoc string
+}
+
+// UsageInfo is everything that is required to write the help documentation.
+type UsageInfo struct {
+	// Title is the Title option, followed by the name of the command, if any.
+	Title string
+	// Doc is the usage info of the command, if any.
+	Doc string
+	// Fields are all the fields that have been registered, in the order of declaration.
+	Fields []FieldInfo
+	// Commands are the subcommands of the program, if any.
+	Commands []Command
 }
 
 // customFlagHolder keeps track of the flagValue, and whether it was ever set or not.
@@ -70,3 +282,78 @@ func (c *customFlagHolder) Set(s string) error {
 	c.flagValue = s
 	return nil
 }
+
+// restPosition is the value of the pos tag that binds all the remaining positional arguments.
+const restPosition = "rest"
+
+// positionalTracker keeps track of the fields that are bound to positional arguments.
+type positionalTracker struct {
+	// positions maps every registered position (an index or "rest") to the path of its field.
+	positions map[string]string
+	// maxIndex is the highest registered index. The "rest" arguments start after it.
+	maxIndex int
+}
+
+// newPositionalTracker returns a new positionalTracker with no registered positions.
+func newPositionalTracker() *positionalTracker {
+	return &positionalTracker{positions: map[string]string{}, maxIndex: -1}
+}
+
+// register records the position of the field, if it has a pos tag.
+func (p *positionalTracker) register(opts *LoaderOptions, parents []rsf, field rsf) error {
+	position, present := field.Tag.Lookup(opts.PosTagName)
+	if !present {
+		return nil
+	}
+
+	fieldName := formatNestedFieldName(parents, field)
+
+	if position != restPosition {
+		index, err := strconv.Atoi(position)
+		if err != nil || index < 0 {
+			return fmt.Errorf(`field "%s" has an invalid position: "%s"`, fieldName, position)
+		}
+		if index > p.maxIndex {
+			p.maxIndex = index
+		}
+		// Normalizing the position, so that "01" and "1" are treated the same.
+		position = strconv.Itoa(index)
+	}
+
+	if existing, exists := p.positions[position]; exists {
+		return fmt.Errorf(`fields "%s" and "%s" have the same position: "%s"`, existing, fieldName, position)
+	}
+
+	p.positions[position] = fieldName
+	return nil
+}
+
+// lookup provides the positional arguments for the given position from the parsed args.
+func (p *positionalTracker) lookup(args []string, position string) (values []string, exists bool) {
+	if position == restPosition {
+		if len(args) <= p.maxIndex+1 {
+			return nil, false
+		}
+		return args[p.maxIndex+1:], true
+	}
+
+	index, err := strconv.Atoi(position)
+	if err != nil || index < 0 || index >= len(args) {
+		return nil, false
+	}
+	return args[index : index+1], true
+}
+
+// sorted provides all registered positions in order, with "rest" at the end.
+func (p *positionalTracker) sorted() []string {
+	positions := make([]string, 0, len(p.positions))
+	for index := 0; index <= p.maxIndex; index++ {
+		if _, exists := p.positions[strconv.Itoa(index)]; exists {
+			positions = append(positions, strconv.Itoa(index))
+		}
+	}
+	if _, exists := p.positions[restPosition]; exists {
+		positions = append(positions, restPosition)
+	}
+	return positions
+}
@@ -0,0 +1,179 @@
+package confetti
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// notProvided is shown in the help documentation in place of absent values.
+const notProvided = "-"
+
+// DefaultUsage writes the default help documentation that shows up on "-h" or "-help".
+//
+// The fields are listed in sections, one per nested struct, with aligned columns for the flag name,
+// type, default value, environment variable and doc. It can be used by a custom Usage option as well.
+func DefaultUsage(output io.Writer, info UsageInfo) {
+	_, _ = fmt.Fprintf(output, "Usage of %s:\n", info.Title)
+	if info.Doc != "" {
+		_, _ = fmt.Fprintf(output, "%s\n", info.Doc)
+	}
+
+	if sections := groupFields(info.Fields); len(sections) > 0 {
+		_, _ = fmt.Fprintln(output)
+		writeFieldTable(output, sections)
+	}
+
+	if len(info.Commands) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(output, "\nCommands:\n")
+	commandRows := make([][]string, 0, len(info.Commands))
+	for _, cmd := range info.Commands {
+		commandRows = append(commandRows, []string{cmd.Name, cmd.Doc})
+	}
+	writeAlignedRows(output, commandRows, nil)
+
+	_, _ = fmt.Fprintf(output, "\nUse \"%s <command> -h\" for the help documentation of a command.\n", info.Title)
+}
+
+// writeFieldTable writes the sections of fields with aligned columns for the flag name,
+// type, default value, environment variable and doc.
+func writeFieldTable(output io.Writer, sections [][]FieldInfo) {
+	// The header is the first row, so that it gets aligned with the rest.
+	rows := [][]string{{"FLAG", "TYPE", "DEFAULT", "ENV", "DOC"}}
+	// headings maps the index of a row to the section heading that precedes it.
+	headings := map[int]string{}
+
+	for _, section := range sections {
+		if section[0].Group != "" {
+			headings[len(rows)] = formatHeading(section[0])
+		}
+		for _, field := range section {
+			rows = append(rows, formatUsageRow(field))
+		}
+	}
+
+	writeAlignedRows(output, rows, headings)
+}
+
+// groupFields splits the fields into sections by their Group, in the order of first appearance.
+// The fields that have no Group come first. Fields that cannot be provided by the user are left out.
+func groupFields(fields []FieldInfo) [][]FieldInfo {
+	// The first section is reserved for the fields that have no Group.
+	sections := [][]FieldInfo{nil}
+	indices := map[string]int{"": 0}
+
+	for _, field := range fields {
+		if field.FlagName == "" && field.Position == "" && field.Env == "" {
+			continue
+		}
+
+		index, exists := indices[field.Group]
+		if !exists {
+			index = len(sections)
+			indices[field.Group] = index
+			sections = append(sections, nil)
+		}
+		sections[index] = append(sections[index], field)
+	}
+
+	// Removing the first section if it is empty.
+	if len(sections[0]) == 0 {
+		sections = sections[1:]
+	}
+	return sections
+}
+
+// formatHeading creates the section heading of the field's group.
+func formatHeading(field FieldInfo) string {
+	if field.GroupDoc == "" {
+		return fmt.Sprintf("%s:", field.Group)
+	}
+	return fmt.Sprintf("%s: %s", field.Group, field.GroupDoc)
+}
+
+// formatUsageRow creates the columns of a field for the help documentation.
+func formatUsageRow(field FieldInfo) []string {
+	name := notProvided
+	switch {
+	case field.FlagName != "":
+		name = "-" + field.FlagName
+	case field.Position == restPosition:
+		name = "<rest>..."
+	case field.Position != "":
+		name = fmt.Sprintf("<%s>", field.Position)
+	}
+
+	typeName := field.TypeName
+	if typeName == "" && field.Type != nil {
+		typeName = friendlyTypeName(field.Type)
+	}
+
+	defValue := notProvided
+	if field.HasDefault {
+		defValue = displayDefault(field)
+	}
+
+	return []string{name, valueOr(typeName, notProvided), defValue, valueOr(field.Env, notProvided), formatUsageDoc(field)}
+}
+
+// formatUsageDoc creates the doc column of a field, which also shows its validations and example.
+func formatUsageDoc(field FieldInfo) string {
+	parts := []string{valueOr(field.Doc, notProvided)}
+	if field.Required {
+		parts = append(parts, "[required]")
+	}
+	if len(field.OneOf) > 0 {
+		parts = append(parts, fmt.Sprintf("[one of: %s]", strings.Join(field.OneOf, ", ")))
+	}
+	if field.Example != "" {
+		parts = append(parts, fmt.Sprintf("[example: %s]", field.Example))
+	}
+	if field.File {
+		parts = append(parts, "[read from file]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// writeAlignedRows writes the rows with all columns aligned, each row being indented by two spaces.
+// The headings are written before the rows at their indices, with a blank line before them.
+func writeAlignedRows(output io.Writer, rows [][]string, headings map[int]string) {
+	var widths []int
+	for _, row := range rows {
+		for col, cell := range row {
+			if col == len(widths) {
+				widths = append(widths, 0)
+			}
+			if len(cell) > widths[col] {
+				widths[col] = len(cell)
+			}
+		}
+	}
+
+	for ind, row := range rows {
+		if heading, exists := headings[ind]; exists {
+			_, _ = fmt.Fprintf(output, "\n%s\n", heading)
+		}
+
+		cells := make([]string, len(row))
+		for col, cell := range row {
+			// The last column is not padded, to avoid trailing spaces.
+			if col == len(row)-1 {
+				cells[col] = cell
+				continue
+			}
+			cells[col] = cell + strings.Repeat(" ", widths[col]-len(cell))
+		}
+		_, _ = fmt.Fprintf(output, "  %s\n", strings.Join(cells, "  "))
+	}
+}
+
+// valueOr returns the value if it is not empty, otherwise the fallback.
+func valueOr(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
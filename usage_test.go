@@ -0,0 +1,51 @@
+package confetti
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// TestDefaultUsage tests if DefaultUsage writes the fields in aligned sections, along with the commands.
+func TestDefaultUsage(t *testing.T) {
+	info := UsageInfo{
+		Title: "configs serve",
+		Doc:   "Starts the server.",
+		Fields: []FieldInfo{
+			{
+				FlagName: "log-level", Type: reflect.TypeOf(""), Default: "info", HasDefault: true, Env: "LOG_LEVEL",
+				OneOf: []string{"debug", "info"}, Example: "debug",
+			},
+			{FlagName: "http-port", Type: reflect.TypeOf(0), Env: "HTTP_PORT", Doc: "HTTP port", Group: "HTTP", GroupDoc: "HTTP server"},
+			{Position: "0", Type: reflect.TypeOf(""), Doc: "Source", Required: true},
+			// Not documented, as it cannot be provided by the user.
+			{Type: reflect.TypeOf(""), Group: "HTTP"},
+			{Env: "HTTP_HOST", Type: reflect.TypeOf([]string{}), Group: "HTTP"},
+		},
+		Commands: []Command{{Name: "serve", Doc: "Starts the server."}},
+	}
+
+	expected := `Usage of configs serve:
+Starts the server.
+
+  FLAG        TYPE            DEFAULT  ENV        DOC
+  -log-level  string          info     LOG_LEVEL  - [one of: debug, info] [example: debug]
+  <0>         string          -        -          Source [required]
+
+HTTP: HTTP server
+  -http-port  int             -        HTTP_PORT  HTTP port
+  -           list of string  -        HTTP_HOST  -
+
+Commands:
+  serve  Starts the server.
+
+Use "configs serve <command> -h" for the help documentation of a command.
+`
+
+	output := &bytes.Buffer{}
+	DefaultUsage(output, info)
+
+	if output.String() != expected {
+		t.Errorf("Expected usage:\n%s\nbut got:\n%s", expected, output.String())
+	}
+}
@@ -2,11 +2,18 @@ package confetti
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// durationType is the reflect.Type of time.Duration.
+var durationType = reflect.TypeOf(time.Duration(0))
+
 // isStructPointer returns true if the input is a struct pointer, otherwise false.
 func isStructPointer(input interface{}) bool {
 	value := reflect.ValueOf(input)
@@ -30,6 +37,122 @@ func getFlagNameAndDoc(tagValue string, sep string) (flagName string, flagDoc st
 	}
 }
 
+// newFieldInfo reads all the struct tags of a field to create its FieldInfo.
+func newFieldInfo(opts *LoaderOptions, parents []rsf, field rsf) FieldInfo {
+	info := FieldInfo{
+		Path:     formatNestedFieldName(parents, field),
+		Type:     field.Type,
+		TypeName: friendlyTypeName(field.Type),
+		Tag:      field.Tag,
+	}
+
+	if argTagValue, present := field.Tag.Lookup(opts.ArgTagName); present {
+		info.FlagName, info.Doc = getFlagNameAndDoc(argTagValue, ",")
+	}
+
+	info.Default, info.HasDefault = field.Tag.Lookup(opts.DefTagName)
+	info.Env = field.Tag.Get(opts.EnvTagName)
+	info.Position = field.Tag.Get(opts.PosTagName)
+	info.Required = isRequired(opts, field)
+	if oneOf := strings.Fields(field.Tag.Get(opts.OneOfTagName)); len(oneOf) > 0 {
+		info.OneOf = oneOf
+	}
+	info.Example = field.Tag.Get(opts.ExampleTagName)
+	info.Secret = isSecret(opts, field)
+	info.File, _ = strconv.ParseBool(field.Tag.Get(opts.FileTagName))
+	if isBytesType(field.Type) {
+		info.Encoding = bytesEncoding(opts, field)
+	}
+
+	// Any path other than a directory is completed as a file. The values of fields with the file tag are files too.
+	if pathKind := field.Tag.Get(opts.PathTagName); pathKind == pathKindDir {
+		info.PathKind = pathKindDir
+	} else if pathKind != "" || info.File {
+		info.PathKind = pathKindFile
+	}
+
+	// The group is decided by the closest parent.
+	if len(parents) > 0 {
+		parent := parents[len(parents)-1]
+		info.Group, info.GroupDoc = getFlagNameAndDoc(parent.Tag.Get(opts.GroupTagName), ",")
+		if info.Group == "" {
+			info.Group = formatNestedFieldName(parents[:len(parents)-1], parent)
+		}
+	}
+	return info
+}
+
+// isRequired returns true if the field must have a value. That is the case if the required tag is "true",
+// or if the field is an indexed positional argument with no default value.
+func isRequired(opts *LoaderOptions, field rsf) bool {
+	if required, _ := strconv.ParseBool(field.Tag.Get(opts.RequiredTagName)); required {
+		return true
+	}
+
+	position, present := field.Tag.Lookup(opts.PosTagName)
+	_, hasDefault := field.Tag.Lookup(opts.DefTagName)
+	return present && position != restPosition && !hasDefault
+}
+
+// isListType returns true if the type is a slice or an array, other than a byte slice.
+// The values of list types are JSON arrays.
+func isListType(fieldType reflect.Type) bool {
+	kind := fieldType.Kind()
+	return (kind == reflect.Slice || kind == reflect.Array) && !isBytesType(fieldType)
+}
+
+// friendlyTypeName provides a user-friendly name of the given type for the documentation.
+// Examples: "int", "duration", "list of string", "map of string to int".
+func friendlyTypeName(fieldType reflect.Type) string {
+	switch {
+	case fieldType == durationType:
+		return "duration"
+	case isBytesType(fieldType):
+		return "bytes"
+	}
+
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "uint"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Slice, reflect.Array:
+		return "list of " + friendlyTypeName(fieldType.Elem())
+	case reflect.Map:
+		return fmt.Sprintf("map of %s to %s", friendlyTypeName(fieldType.Key()), friendlyTypeName(fieldType.Elem()))
+	case reflect.Ptr:
+		return friendlyTypeName(fieldType.Elem())
+	case reflect.Struct:
+		return "object"
+	case reflect.Interface:
+		return "any"
+	default:
+		// Covers string, bool and others whose kind name is good enough.
+		return fieldType.Kind().String()
+	}
+}
+
+// value2Interface converts string values to JSON as per the field type.
+//
+// It handles the types that need special treatment, and uses string2Interface for the rest.
+// The encoding is used only if the type is a byte slice.
+func value2Interface(fieldType reflect.Type, value string, encoding string) (interface{}, error) {
+	// Durations are accepted in the time.ParseDuration format, as well as plain nanoseconds.
+	if fieldType == durationType {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return int64(duration), nil
+		}
+	}
+
+	if isBytesType(fieldType) {
+		return bytes2Interface(encoding, value)
+	}
+
+	return string2Interface(fieldType.Kind(), value)
+}
+
 // string2Interface converts string values to JSON.
 //
 // Note that int, float, booleans etc. are also valid JSON.
@@ -45,6 +168,55 @@ func string2Interface(kind reflect.Kind, value string) (interface{}, error) {
 	return converted, nil
 }
 
+// positionals2Interface converts positional arguments to JSON.
+//
+// A single argument is converted like any other value. Multiple arguments, which come from
+// the "rest" position, require a slice or array type and are converted one element at a time.
+// Byte slices are single values, decoded as per the encoding.
+func positionals2Interface(fieldType reflect.Type, values []string, encoding string) (interface{}, error) {
+	kind := fieldType.Kind()
+	if (kind != reflect.Slice && kind != reflect.Array) || isBytesType(fieldType) {
+		if len(values) != 1 {
+			return nil, fmt.Errorf("expected a single positional argument, got: %d", len(values))
+		}
+		return value2Interface(fieldType, values[0], encoding)
+	}
+
+	converted := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		element, err := value2Interface(fieldType.Elem(), value, encoding)
+		if err != nil {
+			return nil, err
+		}
+		converted = append(converted, element)
+	}
+	return converted, nil
+}
+
+// readValueFile reads the file at the given path. The content is trimmed of the leading and trailing
+// white space, like the newline that most editors add at the end of a file.
+func readValueFile(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("the path is empty")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// containsString returns true if the slice contains the value.
+func containsString(slice []string, value string) bool {
+	for _, element := range slice {
+		if element == value {
+			return true
+		}
+	}
+	return false
+}
+
 // formatNestedFieldName accepts a field and its parents to create a formatted name string.
 // Example: Parent1.Parent2.MyField
 func formatNestedFieldName(parents []rsf, field rsf) string {
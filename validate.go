@@ -0,0 +1,65 @@
+package confetti
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// checkMissing provides the error of a field that got no value from any source.
+// It is nil unless the field is required, as per isRequired.
+func (i *implResolver) checkMissing(field rsf) error {
+	if !isRequired(i.opts, field) {
+		return nil
+	}
+	if position, present := field.Tag.Lookup(i.opts.PosTagName); present {
+		return fmt.Errorf("missing positional argument: %s", position)
+	}
+	return errors.New("missing required value")
+}
+
+// checkOneOfValue is the same as checkOneOf, but for a single value of any source.
+// The value of a list field is a JSON array, so each of its elements is checked instead.
+func (i *implResolver) checkOneOfValue(field rsf, stringValue string) error {
+	if !isListType(field.Type) || field.Tag.Get(i.opts.OneOfTagName) == "" {
+		return i.checkOneOf(field, stringValue)
+	}
+
+	var elements []interface{}
+	if err := json.Unmarshal([]byte(stringValue), &elements); err != nil {
+		return err
+	}
+
+	// The elements are checked in their string forms, like the values of scalar fields.
+	stringValues := make([]string, 0, len(elements))
+	for _, element := range elements {
+		if str, isString := element.(string); isString {
+			stringValues = append(stringValues, str)
+			continue
+		}
+		encoded, _ := json.Marshal(element)
+		stringValues = append(stringValues, string(encoded))
+	}
+	return i.checkOneOf(field, stringValues...)
+}
+
+// checkOneOf returns an error if any of the values is not allowed by the oneof tag of the field.
+// The values are the elements for list fields. Maps and structs cannot have the oneof tag.
+func (i *implResolver) checkOneOf(field rsf, stringValues ...string) error {
+	allowed := strings.Fields(field.Tag.Get(i.opts.OneOfTagName))
+	if len(allowed) == 0 {
+		return nil
+	}
+	if kind := field.Type.Kind(); kind == reflect.Map || kind == reflect.Struct {
+		return fmt.Errorf("the oneof tag is not supported for the %s kind", kind)
+	}
+
+	for _, stringValue := range stringValues {
+		if !containsString(allowed, stringValue) {
+			return fmt.Errorf(`value "%s" is not one of: %s`, stringValue, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package confetti
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestImplResolver_ResolveField_Validations tests if ResolveField enforces the required and oneof tags.
+func TestImplResolver_ResolveField_Validations(t *testing.T) {
+	instance := &implResolver{opts: defaultLoaderOptions, lookupEnv: os.LookupEnv}
+	flagger := &implMockFlagger{argMap: map[string]string{"df-3": "trace"}, posMap: map[string][]string{}}
+
+	dummyTarget := struct {
+		// Required, but has a value.
+		dummyField1 string `def:"1" required:"true"`
+		// Required, and has no value.
+		dummyField2 string `env:"CONFETTI_MISSING_DF2" required:"true"`
+		// The value is not allowed.
+		dummyField3 string `arg:"df-3" oneof:"debug info"`
+		// The value is allowed.
+		dummyField4 string `def:"info" oneof:"debug info"`
+	}{}
+
+	// The expected errors, one per field.
+	expectErr := []bool{false, true, true, false}
+
+	structType := reflect.TypeOf(dummyTarget)
+	for ind := 0; ind < structType.NumField(); ind++ {
+		fieldType := structType.Field(ind)
+
+		_, _, err := instance.ResolveField(nil, &fieldType, flagger)
+		if expectErr[ind] != (err != nil) {
+			t.Errorf("Unexpected error for field %s: %+v", fieldType.Name, err)
+		}
+	}
+}
+
+// TestImplResolver_ResolveField_OneOfList tests if the oneof tag of list fields checks every element,
+// and if it is rejected for maps.
+func TestImplResolver_ResolveField_OneOfList(t *testing.T) {
+	instance := &implResolver{opts: defaultLoaderOptions, lookupEnv: os.LookupEnv}
+	flagger := &implMockFlagger{argMap: map[string]string{}}
+
+	dummyTarget := struct {
+		// All elements are allowed.
+		dummyField1 []string `def:"[\"debug\", \"info\"]" oneof:"debug info"`
+		// An element is not allowed.
+		dummyField2 []string `def:"[\"debug\", \"trace\"]" oneof:"debug info"`
+		// The elements are checked in their string forms.
+		dummyField3 []int `def:"[1, 2]" oneof:"1 2 3"`
+		// Maps cannot have the oneof tag.
+		dummyField4 map[string]string `def:"{}" oneof:"a b"`
+	}{}
+
+	// The expected errors, one per field.
+	expectErr := []bool{false, true, false, true}
+
+	structType := reflect.TypeOf(dummyTarget)
+	for ind := 0; ind < structType.NumField(); ind++ {
+		fieldType := structType.Field(ind)
+
+		_, _, err := instance.ResolveField(nil, &fieldType, flagger)
+		if expectErr[ind] != (err != nil) {
+			t.Errorf("Unexpected error for field %s: %+v", fieldType.Name, err)
+		}
+	}
+}
+
+// TestImplLoader_Load_Validations tests if Load reports the missing required values and the values
+// that are not allowed, with the paths of their fields.
+func TestImplLoader_Load_Validations(t *testing.T) {
+	type validatedConfigs struct {
+		LogLevel string `env:"CONFETTI_VALIDATE_LEVEL" def:"info" oneof:"debug info"`
+		DB       struct {
+			URL string `env:"CONFETTI_VALIDATE_URL" required:"true"`
+		}
+	}
+
+	err := NewLoader(LoaderOptions{Args: []string{}}).Load(&validatedConfigs{})
+	if err == nil || !strings.Contains(err.Error(), `"DB.URL" missing required value`) {
+		t.Errorf("Expected a missing required value error, got: %+v", err)
+	}
+
+	_ = os.Setenv("CONFETTI_VALIDATE_URL", "postgres://localhost")
+	_ = os.Setenv("CONFETTI_VALIDATE_LEVEL", "trace")
+	defer func() {
+		_ = os.Unsetenv("CONFETTI_VALIDATE_URL")
+		_ = os.Unsetenv("CONFETTI_VALIDATE_LEVEL")
+	}()
+
+	err = NewLoader(LoaderOptions{Args: []string{}}).Load(&validatedConfigs{})
+	if err == nil || !strings.Contains(err.Error(), `value "trace" is not one of: debug, info`) {
+		t.Errorf("Expected a oneof error, got: %+v", err)
+	}
+
+	_ = os.Setenv("CONFETTI_VALIDATE_LEVEL", "debug")
+	target := &validatedConfigs{}
+	if err := NewLoader(LoaderOptions{Args: []string{}}).Load(target); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+	if target.LogLevel != "debug" || target.DB.URL != "postgres://localhost" {
+		t.Errorf("Unexpected target: %+v", target)
+	}
+}
+
+// TestImplLoader_Load_Durations tests if Load accepts durations in the time.ParseDuration format
+// from all sources, including the positional arguments.
+func TestImplLoader_Load_Durations(t *testing.T) {
+	_ = os.Setenv("CONFETTI_VALIDATE_TIMEOUT", "1m30s")
+	defer func() { _ = os.Unsetenv("CONFETTI_VALIDATE_TIMEOUT") }()
+
+	target := &struct {
+		Timeout  time.Duration   `env:"CONFETTI_VALIDATE_TIMEOUT"`
+		Interval time.Duration   `arg:"interval"`
+		Delays   []time.Duration `pos:"rest"`
+	}{}
+
+	opts := LoaderOptions{Args: []string{"-interval", "250ms", "1s", "2000"}}
+	if err := NewLoader(opts).Load(target); err != nil {
+		t.Errorf("Expected Load error: nil, got: %+v", err)
+		return
+	}
+
+	expected := []time.Duration{time.Second, 2000}
+	if target.Timeout != 90*time.Second || target.Interval != 250*time.Millisecond || !reflect.DeepEqual(target.Delays, expected) {
+		t.Errorf("Unexpected target: %+v", target)
+	}
+}
@@ -0,0 +1,513 @@
+package confetti
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// defaultWatchInterval is the default interval of the file checks of a Watcher.
+const defaultWatchInterval = time.Second
+
+const (
+	// reloadStatic is the value of the reload tag for the fields that cannot change without a restart.
+	reloadStatic = "static"
+	// reloadDynamic is the value of the reload tag for the fields that can change live. It is the default.
+	reloadDynamic = "dynamic"
+)
+
+// StaticPolicy controls what a Watcher does when a reload changes the fields with the `reload:"static"` tag,
+// like ports and database DSNs, which are not safe to change live.
+type StaticPolicy string
+
+const (
+	// StaticPolicyReport applies the changes of the dynamic fields, while the static fields keep their
+	// previous values. The changes of the static fields are reported in Snapshot.RestartRequired. It is the default.
+	StaticPolicyReport StaticPolicy = "report"
+	// StaticPolicyReject rejects the whole reload with an ErrRestartRequired, so no change applies.
+	StaticPolicyReject StaticPolicy = "reject"
+)
+
+// WatchOptions controls the behaviour of a Watcher.
+type WatchOptions struct {
+	// Files are the files or directories to watch, in addition to the .env file (if UseDotEnv is set)
+	// and the ConfigDir (if set). For example, the files that are read through the file tag.
+	Files []string
+	// Interval is how often the files are checked for changes. It defaults to one second.
+	Interval time.Duration
+	// Signals are the signals that trigger a reload. They default to SIGHUP.
+	Signals []os.Signal
+	// StaticPolicy controls what happens when a reload changes the static fields. It defaults to StaticPolicyReport.
+	StaticPolicy StaticPolicy
+	// Holder, if set, holds the target from the start, and then the Config of every new Snapshot.
+	// It is swapped before the OnChange call.
+	Holder *Holder
+	// OnChange is called with every new Snapshot that has changes. The static fields that require a restart
+	// keep their previous values, so they are delivered again only along with other changes, or if they change
+	// again. It is called by one goroutine at a time, and it must not call Reload.
+	OnChange func(snapshot Snapshot)
+	// OnError is called when a reload that was triggered by a file change or a signal fails.
+	// The previous Snapshot stays in effect.
+	OnError func(err error)
+}
+
+// Snapshot is the result of a single load of a Watcher.
+type Snapshot struct {
+	// Config is a pointer to a new instance of the target type, holding the loaded configs.
+	// It is never modified afterwards, so it can be shared freely.
+	Config interface{}
+	// Reports describe how the value of every field was resolved, like the ones of LoadWithReport.
+	Reports []FieldReport
+	// Changes are the fields whose values differ from the previous Snapshot, in the order of declaration.
+	// They are the dynamic fields only.
+	Changes []FieldChange
+	// RestartRequired are the static fields whose values changed, in the order of declaration.
+	// Their new values take effect only after a restart, so the Snapshot keeps their previous values and reports.
+	RestartRequired []FieldChange
+}
+
+// FieldChange is a field whose value changed between two Snapshots.
+type FieldChange struct {
+	// Path is the name of the field along with the names of all its parents.
+	// Example: Parent1.Parent2.MyField
+	Path string
+	// Old is the previous value of the field.
+	Old interface{}
+	// New is the current value of the field.
+	New interface{}
+	// Secret is true if the field holds sensitive data, in which case String redacts the values.
+	Secret bool
+	// Static is true if the field has the `reload:"static"` tag.
+	Static bool
+}
+
+// String describes the change, like "LogLevel: info -> debug". The values of secret fields are redacted.
+func (f FieldChange) String() string {
+	if f.Secret {
+		return fmt.Sprintf("%s: %s -> %s", f.Path, RedactedValue, RedactedValue)
+	}
+	return fmt.Sprintf("%s: %v -> %v", f.Path, f.Old, f.New)
+}
+
+// Watcher reloads the configs when the watched files change or when a signal is received,
+// and delivers the new Snapshots along with the changed fields.
+//
+// Every reload resolves all sources again, exactly like a Load call with the same LoaderOptions.
+// The changes of the .env file apply to the variables that were set by it, not to the ones set by the environment.
+// Unlike Load, the reloads read the .env file without setting its variables in the process environment,
+// so the concurrent Load calls and the rest of the program never see them change.
+type Watcher struct {
+	// loader loads the Snapshots.
+	loader *Loader
+	// targetType is the struct type of the target.
+	targetType reflect.Type
+	// watchOpts keeps the WatchOptions.
+	watchOpts WatchOptions
+	// paths are all watched files and directories.
+	paths []string
+	// dotEnvKeys are the environment variables that were set by the .env file on the first load, and not by
+	// the environment. The reloads take them from the current .env file instead of the process environment.
+	dotEnvKeys map[string]bool
+
+	// reloadMutex serializes the reloads, along with their OnChange calls, and guards the fields below.
+	reloadMutex sync.Mutex
+	// fingerprint describes the state of the watched paths as of the latest check.
+	fingerprint string
+	// restartRequired are the static changes of the latest delivered Snapshot, as per StaticPolicyReport.
+	restartRequired []FieldChange
+
+	// mutex guards the fields below.
+	mutex sync.Mutex
+	// current is the latest Snapshot.
+	current Snapshot
+	// stop stops the watching goroutine. It is nil if the Watcher is not started.
+	stop chan struct{}
+	// done is closed when the watching goroutine returns.
+	done chan struct{}
+}
+
+// NewWatcher loads the configs into the target, like ILoader.Load, and provides a Watcher that can reload them.
+// The target is the first Snapshot. The later Snapshots are new instances of the target type, so the
+// target itself never changes. The Watcher does not watch anything until Start is called.
+func NewWatcher(target interface{}, opts LoaderOptions, watchOpts WatchOptions) (*Watcher, error) {
+	if !isStructPointer(target) {
+		return nil, errors.New("target must be a struct pointer")
+	}
+	if opts.FlagSet != nil {
+		return nil, errors.New("NewWatcher cannot be used with the FlagSet option")
+	}
+
+	loader := newLoader(opts)
+	watcher := &Watcher{
+		loader:     loader,
+		targetType: reflect.TypeOf(target).Elem(),
+		watchOpts:  watchOpts,
+		paths:      append([]string{}, watchOpts.Files...),
+		dotEnvKeys: map[string]bool{},
+	}
+	if loader.opts.UseDotEnv {
+		watcher.paths = append(watcher.paths, dotEnvFile)
+	}
+	if loader.opts.ConfigDir != "" {
+		watcher.paths = append(watcher.paths, loader.opts.ConfigDir)
+	}
+	if watcher.watchOpts.StaticPolicy == "" {
+		watcher.watchOpts.StaticPolicy = StaticPolicyReport
+	}
+	if watcher.watchOpts.StaticPolicy != StaticPolicyReport && watcher.watchOpts.StaticPolicy != StaticPolicyReject {
+		return nil, fmt.Errorf("unknown static policy: %s", watcher.watchOpts.StaticPolicy)
+	}
+	if watcher.watchOpts.Interval <= 0 {
+		watcher.watchOpts.Interval = defaultWatchInterval
+	}
+	if len(watcher.watchOpts.Signals) == 0 {
+		watcher.watchOpts.Signals = []os.Signal{syscall.SIGHUP}
+	}
+
+	// The reload tags are validated upfront, by comparing the target with itself.
+	if _, err := watcher.diff(target, target); err != nil {
+		return nil, err
+	}
+
+	// The fingerprint is taken before loading, so that no change goes unnoticed.
+	watcher.fingerprint = fingerprintPaths(watcher.paths)
+	watcher.trackDotEnv()
+
+	// The first load sets the variables of the .env file in the process environment, exactly like Load.
+
+	reports, err := loader.LoadWithReport(target)
+	if err != nil {
+		return nil, err
+	}
+	watcher.current = Snapshot{Config: target, Reports: reports}
+
+	if watchOpts.Holder != nil {
+		if _, err := watchOpts.Holder.Swap(target); err != nil {
+			return nil, fmt.Errorf("failed to hold configs: %w", err)
+		}
+	}
+	return watcher, nil
+}
+
+// Current provides the latest Snapshot.
+func (w *Watcher) Current() Snapshot {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.current
+}
+
+// Reload loads the configs again. If any field changed, the new Snapshot becomes the current one
+// and it is delivered to the OnChange callback. The static fields that require a restart are reported
+// once, unless they change again, so a Snapshot with only the same RestartRequired is not delivered again. If the load fails, or if it is rejected as per the
+// StaticPolicy, the current Snapshot stays in effect.
+func (w *Watcher) Reload() error {
+	w.reloadMutex.Lock()
+	defer w.reloadMutex.Unlock()
+	return w.reload()
+}
+
+// Start starts watching the files and the signals in a new goroutine. It does nothing if the Watcher is started.
+func (w *Watcher) Start() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.stop != nil {
+		return
+	}
+
+	w.stop, w.done = make(chan struct{}), make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, w.watchOpts.Signals...)
+
+	go w.watch(signals, w.stop, w.done)
+}
+
+// Stop stops watching and waits for any ongoing reload to finish. It does nothing if the Watcher is not started.
+func (w *Watcher) Stop() {
+	w.mutex.Lock()
+	stop, done := w.stop, w.done
+	w.stop, w.done = nil, nil
+	w.mutex.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// watch reloads the configs on every signal and on every change of the watched paths, until stopped.
+func (w *Watcher) watch(signals chan os.Signal, stop chan struct{}, done chan struct{}) {
+	defer close(done)
+	defer signal.Stop(signals)
+
+	ticker := time.NewTicker(w.watchOpts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-signals:
+			w.reloadAndReport(true)
+		case <-ticker.C:
+			w.reloadAndReport(false)
+		}
+	}
+}
+
+// reloadAndReport reloads the configs if forced or if the watched paths changed,
+// and reports the error to the OnError callback.
+func (w *Watcher) reloadAndReport(force bool) {
+	w.reloadMutex.Lock()
+	defer w.reloadMutex.Unlock()
+
+	fingerprint := fingerprintPaths(w.paths)
+	if !force && fingerprint == w.fingerprint {
+		return
+	}
+	w.fingerprint = fingerprint
+
+	if err := w.reload(); err != nil && w.watchOpts.OnError != nil {
+		w.watchOpts.OnError(err)
+	}
+}
+
+// reload loads a new Snapshot and delivers it if it has changes. It must be called with the reloadMutex held.
+func (w *Watcher) reload() error {
+	target := reflect.New(w.targetType).Interface()
+	reports, _, err := w.loader.withLookupEnv(w.dotEnvLookup()).load(target)
+	if err != nil {
+		return fmt.Errorf("failed to reload configs: %w", err)
+	}
+
+	current := w.Current()
+	changes, err := w.diff(current.Config, target)
+	if err != nil {
+		return fmt.Errorf("failed to compare configs: %w", err)
+	}
+
+	if len(changes) == 0 {
+		w.restartRequired = nil
+		return nil
+	}
+
+	// The fields that were interpolated from secret fields are redacted like the secrets themselves.
+	secrets := secretPaths(current.Reports, reports)
+
+	snapshot := Snapshot{Config: target, Reports: reports}
+	for _, change := range changes {
+		change.Secret = change.Secret || secrets[change.Path]
+		if change.Static {
+			snapshot.RestartRequired = append(snapshot.RestartRequired, change)
+		} else {
+			snapshot.Changes = append(snapshot.Changes, change)
+		}
+	}
+
+	if len(snapshot.RestartRequired) > 0 {
+		if w.watchOpts.StaticPolicy == StaticPolicyReject {
+			paths := make([]string, 0, len(snapshot.RestartRequired))
+			for _, change := range snapshot.RestartRequired {
+				paths = append(paths, change.Path)
+			}
+			return fmt.Errorf("failed to reload configs: %w: %s changed", ErrRestartRequired, strings.Join(paths, ", "))
+		}
+
+		// The static fields keep their previous values, as the program still runs with them.
+		// So, every later reload finds the same static changes again, which are delivered only once.
+		if len(snapshot.Changes) == 0 && reflect.DeepEqual(snapshot.RestartRequired, w.restartRequired) {
+			return nil
+		}
+		if err := w.restoreStatic(current, &snapshot); err != nil {
+			return fmt.Errorf("failed to keep static configs: %w", err)
+		}
+	}
+	w.restartRequired = snapshot.RestartRequired
+
+	w.mutex.Lock()
+	w.current = snapshot
+	w.mutex.Unlock()
+
+	if w.watchOpts.Holder != nil {
+		if _, err := w.watchOpts.Holder.Swap(target); err != nil {
+			return fmt.Errorf("failed to hold configs: %w", err)
+		}
+	}
+	if w.watchOpts.OnChange != nil {
+		w.watchOpts.OnChange(snapshot)
+	}
+	return nil
+}
+
+// trackDotEnv records the variables of the .env file that are not set by the environment, that is,
+// the ones that the first load sets. It must be called before the first load.
+func (w *Watcher) trackDotEnv() {
+	if !w.loader.opts.UseDotEnv {
+		return
+	}
+
+	values, err := godotenv.Read(dotEnvFile)
+	if err != nil {
+		return
+	}
+	for key := range values {
+		if _, present := os.LookupEnv(key); !present {
+			w.dotEnvKeys[key] = true
+		}
+	}
+}
+
+// dotEnvLookup reads the current .env file and provides the lookup of the environment variables for a reload.
+//
+// The variables that were set by the .env file are looked up in the file only, so that their changes
+// and removals apply. The variables that are set by the environment take precedence over the file, as they
+// do for Load. A missing or invalid .env file has no variables.
+func (w *Watcher) dotEnvLookup() envLookup {
+	if !w.loader.opts.UseDotEnv {
+		return os.LookupEnv
+	}
+
+	values, _ := godotenv.Read(dotEnvFile)
+	return func(key string) (string, bool) {
+		if value, present := os.LookupEnv(key); present && !w.dotEnvKeys[key] {
+			return value, true
+		}
+		value, present := values[key]
+		return value, present
+	}
+}
+
+// diff compares the values of all non-struct fields of the two targets (struct pointers).
+func (w *Watcher) diff(oldTarget interface{}, newTarget interface{}) ([]FieldChange, error) {
+	oldValue, newValue := reflect.ValueOf(oldTarget).Elem(), reflect.ValueOf(newTarget).Elem()
+
+	var changes []FieldChange
+	action := func(parents []rsf, field rsf) error {
+		// Nested structs are compared field by field. Unexported fields are never loaded, so they never change.
+		if field.Type.Kind() == reflect.Struct || field.PkgPath != "" {
+			return nil
+		}
+
+		static, err := isStatic(w.loader.opts, field)
+		if err != nil {
+			return fmt.Errorf(`field "%s": %w`, formatNestedFieldName(parents, field), err)
+		}
+
+		oldField := nestedFieldValue(oldValue, parents, field).Interface()
+		newField := nestedFieldValue(newValue, parents, field).Interface()
+		if !reflect.DeepEqual(oldField, newField) {
+			changes = append(changes, FieldChange{
+				Path:   formatNestedFieldName(parents, field),
+				Old:    oldField,
+				New:    newField,
+				Secret: isSecret(w.loader.opts, field),
+				Static: static,
+			})
+		}
+		return nil
+	}
+
+	if err := w.loader.forEachStructField(newValue.Interface(), action, nil); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// restoreStatic sets the static fields of the Snapshot that require a restart back to their previous
+// values and reports.
+func (w *Watcher) restoreStatic(previous Snapshot, snapshot *Snapshot) error {
+	oldValue, newValue := reflect.ValueOf(previous.Config).Elem(), reflect.ValueOf(snapshot.Config).Elem()
+
+	restored := map[string]bool{}
+	for _, change := range snapshot.RestartRequired {
+		restored[change.Path] = true
+	}
+
+	action := func(parents []rsf, field rsf) error {
+		if restored[formatNestedFieldName(parents, field)] {
+			nestedFieldValue(newValue, parents, field).Set(nestedFieldValue(oldValue, parents, field))
+		}
+		return nil
+	}
+	if err := w.loader.forEachStructField(newValue.Interface(), action, nil); err != nil {
+		return err
+	}
+
+	previousReports := map[string]FieldReport{}
+	for _, report := range previous.Reports {
+		previousReports[report.Path] = report
+	}
+	for ind, report := range snapshot.Reports {
+		if restored[report.Path] {
+			snapshot.Reports[ind] = previousReports[report.Path]
+		}
+	}
+	return nil
+}
+
+// secretPaths provides the paths of the fields whose reports are secret, in any of the given report sets.
+func secretPaths(reportSets ...[]FieldReport) map[string]bool {
+	paths := map[string]bool{}
+	for _, reports := range reportSets {
+		for _, report := range reports {
+			if report.Secret {
+				paths[report.Path] = true
+			}
+		}
+	}
+	return paths
+}
+
+// isStatic returns true if the field has the `reload:"static"` tag.
+func isStatic(opts *LoaderOptions, field rsf) (bool, error) {
+	switch policy := field.Tag.Get(opts.ReloadTagName); policy {
+	case reloadStatic:
+		return true, nil
+	case "", reloadDynamic:
+		return false, nil
+	default:
+		return false, fmt.Errorf(`invalid reload tag "%s", must be one of: %s, %s`, policy, reloadStatic, reloadDynamic)
+	}
+}
+
+// nestedFieldValue provides the value of the field inside the given struct value, going through its parents.
+func nestedFieldValue(structValue reflect.Value, parents []rsf, field rsf) reflect.Value {
+	for _, parent := range parents {
+		structValue = reflect.Indirect(structValue.FieldByName(parent.Name))
+	}
+	return structValue.FieldByName(field.Name)
+}
+
+// fingerprintPaths describes the state of the given files and directories, that is, the names, sizes,
+// modification times and modes of all their files, including the hidden ones and the symlinks.
+// Two fingerprints differ if any file was created, deleted, modified or replaced.
+func fingerprintPaths(paths []string) string {
+	var builder strings.Builder
+	for _, path := range paths {
+		// Missing paths are part of the fingerprint too, so that their creation is noticed.
+		_ = filepath.WalkDir(path, func(entryPath string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				builder.WriteString(entryPath + ":missing\n")
+				return nil
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				return nil
+			}
+			builder.WriteString(fmt.Sprintf("%s:%d:%d:%s\n", entryPath, info.Size(), info.ModTime().UnixNano(), info.Mode()))
+			return nil
+		})
+	}
+	return builder.String()
+}
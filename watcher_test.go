@@ -0,0 +1,389 @@
+package confetti
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// watchedConfigs is the target of the Watcher tests.
+type watchedConfigs struct {
+	LogLevel string `env:"CONFETTI_WATCH_LEVEL" def:"info"`
+	Limits   struct {
+		RPS int `env:"CONFETTI_WATCH_RPS" def:"10"`
+	}
+	Token string `env:"CONFETTI_WATCH_TOKEN" secret:"true"`
+}
+
+// waitForSnapshot waits for a Snapshot on the channel, and fails the test if none arrives in time.
+func waitForSnapshot(t *testing.T, snapshots chan Snapshot) Snapshot {
+	t.Helper()
+	select {
+	case snapshot := <-snapshots:
+		return snapshot
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected a Snapshot, got none")
+		return Snapshot{}
+	}
+}
+
+// TestWatcher_Reload tests if a reload delivers a new Snapshot with the changed fields,
+// without modifying the original target.
+func TestWatcher_Reload(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigDirFiles(t, dir, map[string]string{"CONFETTI_WATCH_LEVEL": "debug", "limits/rps": "10"})
+
+	var delivered []Snapshot
+	holder := &Holder{}
+	target := &watchedConfigs{}
+	opts := LoaderOptions{ConfigDir: dir, Args: []string{}}
+	watcher, err := NewWatcher(target, opts, WatchOptions{Holder: holder, OnChange: func(snapshot Snapshot) {
+		// The Holder is swapped before the OnChange call.
+		if holder.Get() != snapshot.Config {
+			t.Errorf("Expected the Holder to hold the new Snapshot")
+		}
+		delivered = append(delivered, snapshot)
+	}})
+	if err != nil {
+		t.Errorf("Expected NewWatcher error: nil, got: %+v", err)
+		return
+	}
+	if watcher.Current().Config != target || holder.Get() != target || target.LogLevel != "debug" {
+		t.Errorf("Expected the target to be the first Snapshot with LogLevel: debug, got: %+v", target)
+	}
+
+	// Without any changes, nothing is delivered.
+	if err := watcher.Reload(); err != nil {
+		t.Errorf("Expected Reload error: nil, got: %+v", err)
+	}
+	if len(delivered) != 0 {
+		t.Errorf("Expected no Snapshots, got: %d", len(delivered))
+	}
+
+	writeConfigDirFiles(t, dir, map[string]string{"CONFETTI_WATCH_LEVEL": "warn", "limits/rps": "100", "CONFETTI_WATCH_TOKEN": "abc"})
+	if err := watcher.Reload(); err != nil {
+		t.Errorf("Expected Reload error: nil, got: %+v", err)
+		return
+	}
+	if len(delivered) != 1 {
+		t.Errorf("Expected Snapshots: 1, got: %d", len(delivered))
+		return
+	}
+
+	expected := []FieldChange{
+		{Path: "LogLevel", Old: "debug", New: "warn"},
+		{Path: "Limits.RPS", Old: 10, New: 100},
+		{Path: "Token", Old: "", New: "abc", Secret: true},
+	}
+	if !reflect.DeepEqual(delivered[0].Changes, expected) {
+		t.Errorf("Expected Changes: %+v, got: %+v", expected, delivered[0].Changes)
+	}
+	if delivered[0].Changes[2].String() != "Token: ****** -> ******" {
+		t.Errorf("Expected the secret change to be redacted, got: %s", delivered[0].Changes[2].String())
+	}
+
+	current := watcher.Current().Config.(*watchedConfigs)
+	if current.LogLevel != "warn" || current.Limits.RPS != 100 {
+		t.Errorf("Expected the current Snapshot to have the new values, got: %+v", current)
+	}
+	if target.LogLevel != "debug" {
+		t.Errorf("Expected the original target to be unchanged, got LogLevel: %s", target.LogLevel)
+	}
+}
+
+// TestWatcher_ReloadError tests if a failed reload keeps the current Snapshot.
+func TestWatcher_ReloadError(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigDirFiles(t, dir, map[string]string{"limits/rps": "10"})
+
+	target := &watchedConfigs{}
+	watcher, err := NewWatcher(target, LoaderOptions{ConfigDir: dir, Args: []string{}}, WatchOptions{})
+	if err != nil {
+		t.Errorf("Expected NewWatcher error: nil, got: %+v", err)
+		return
+	}
+
+	writeConfigDirFiles(t, dir, map[string]string{"limits/rps": "many"})
+	if err := watcher.Reload(); err == nil {
+		t.Errorf("Expected Reload error for an invalid value, got: nil")
+	}
+	if watcher.Current().Config != target {
+		t.Errorf("Expected the current Snapshot to stay in effect")
+	}
+}
+
+// TestWatcher_Start tests if the changes of the watched files and the signals trigger reloads.
+func TestWatcher_Start(t *testing.T) {
+	workDir, _ := os.Getwd()
+	tempDir := t.TempDir()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Errorf("Failed to change the working directory: %+v", err)
+		return
+	}
+	defer func() { _ = os.Chdir(workDir) }()
+	defer func() { _ = os.Unsetenv("CONFETTI_WATCH_LEVEL") }()
+	defer func() { _ = os.Unsetenv("CONFETTI_WATCH_RPS") }()
+
+	// The RPS is set by the environment, so the changes of the .env file do not apply to it.
+	_ = os.Setenv("CONFETTI_WATCH_RPS", "5")
+	writeDotEnv := func(content string) {
+		if err := os.WriteFile(filepath.Join(tempDir, ".env"), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write the .env file: %+v", err)
+		}
+	}
+	writeDotEnv("CONFETTI_WATCH_LEVEL=debug\nCONFETTI_WATCH_RPS=50\n")
+
+	snapshots, errs := make(chan Snapshot, 10), make(chan error, 10)
+	watchOpts := WatchOptions{
+		Interval: 10 * time.Millisecond,
+		OnChange: func(snapshot Snapshot) { snapshots <- snapshot },
+		OnError:  func(err error) { errs <- err },
+	}
+
+	target := &watchedConfigs{}
+	watcher, err := NewWatcher(target, LoaderOptions{UseDotEnv: true, Args: []string{}}, watchOpts)
+	if err != nil {
+		t.Errorf("Expected NewWatcher error: nil, got: %+v", err)
+		return
+	}
+	if target.LogLevel != "debug" || target.Limits.RPS != 5 {
+		t.Errorf("Expected LogLevel and RPS to be: debug, 5, got: %s, %d", target.LogLevel, target.Limits.RPS)
+	}
+
+	watcher.Start()
+	defer watcher.Stop()
+
+	writeDotEnv("CONFETTI_WATCH_LEVEL=error\nCONFETTI_WATCH_RPS=500\n")
+	snapshot := waitForSnapshot(t, snapshots)
+	expected := []FieldChange{{Path: "LogLevel", Old: "debug", New: "error"}}
+	if !reflect.DeepEqual(snapshot.Changes, expected) {
+		t.Errorf("Expected Changes: %+v, got: %+v", expected, snapshot.Changes)
+	}
+
+	// A signal reloads the configs even if no watched file changed.
+	_ = os.Setenv("CONFETTI_WATCH_RPS", "6")
+	process, _ := os.FindProcess(os.Getpid())
+	if err := process.Signal(syscall.SIGHUP); err != nil {
+		t.Errorf("Failed to send the signal: %+v", err)
+		return
+	}
+	snapshot = waitForSnapshot(t, snapshots)
+	expected = []FieldChange{{Path: "Limits.RPS", Old: 5, New: 6}}
+	if !reflect.DeepEqual(snapshot.Changes, expected) {
+		t.Errorf("Expected Changes: %+v, got: %+v", expected, snapshot.Changes)
+	}
+
+	select {
+	case err := <-errs:
+		t.Errorf("Expected no reload errors, got: %+v", err)
+	default:
+	}
+}
+
+// TestWatcher_Reload_DotEnv tests if a reload applies the changes and removals of the .env file
+// without changing the process environment.
+func TestWatcher_Reload_DotEnv(t *testing.T) {
+	workDir, _ := os.Getwd()
+	tempDir := t.TempDir()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Errorf("Failed to change the working directory: %+v", err)
+		return
+	}
+	defer func() { _ = os.Chdir(workDir) }()
+	defer func() { _ = os.Unsetenv("CONFETTI_WATCH_LEVEL") }()
+
+	writeDotEnv := func(content string) {
+		if err := os.WriteFile(filepath.Join(tempDir, ".env"), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write the .env file: %+v", err)
+		}
+	}
+	writeDotEnv("CONFETTI_WATCH_LEVEL=debug\n")
+
+	watcher, err := NewWatcher(&watchedConfigs{}, LoaderOptions{UseDotEnv: true, Args: []string{}}, WatchOptions{})
+	if err != nil {
+		t.Errorf("Expected NewWatcher error: nil, got: %+v", err)
+		return
+	}
+
+	writeDotEnv("CONFETTI_WATCH_LEVEL=error\n")
+	if err := watcher.Reload(); err != nil {
+		t.Errorf("Expected Reload error: nil, got: %+v", err)
+		return
+	}
+	current := watcher.Current()
+	if level := current.Config.(*watchedConfigs).LogLevel; level != "error" {
+		t.Errorf("Expected LogLevel to be: error, got: %s", level)
+	}
+	if current.Reports[0].Source != SourceDotEnv {
+		t.Errorf("Expected the source of LogLevel to be: %s, got: %s", SourceDotEnv, current.Reports[0].Source)
+	}
+	// The process environment keeps the value of the first load.
+	if level := os.Getenv("CONFETTI_WATCH_LEVEL"); level != "debug" {
+		t.Errorf("Expected the environment variable to be unchanged, got: %s", level)
+	}
+
+	// A variable removed from the .env file falls back to the default.
+	writeDotEnv("")
+	if err := watcher.Reload(); err != nil {
+		t.Errorf("Expected Reload error: nil, got: %+v", err)
+		return
+	}
+	if level := watcher.Current().Config.(*watchedConfigs).LogLevel; level != "info" {
+		t.Errorf("Expected LogLevel to be: info, got: %s", level)
+	}
+	if level := os.Getenv("CONFETTI_WATCH_LEVEL"); level != "debug" {
+		t.Errorf("Expected the environment variable to be unchanged, got: %s", level)
+	}
+}
+
+// TestNewWatcher_Errors tests if invalid targets and failed initial loads result in errors.
+func TestNewWatcher_Errors(t *testing.T) {
+	if _, err := NewWatcher(watchedConfigs{}, LoaderOptions{Args: []string{}}, WatchOptions{}); err == nil {
+		t.Errorf("Expected NewWatcher error for a non-pointer target, got: nil")
+	}
+
+	target := &struct {
+		Port int `def:"eighty"`
+	}{}
+	if _, err := NewWatcher(target, LoaderOptions{Args: []string{}}, WatchOptions{}); err == nil {
+		t.Errorf("Expected NewWatcher error for an invalid default, got: nil")
+	}
+}
+
+// staticConfigs is the target of the static reload policy tests.
+type staticConfigs struct {
+	Port     int    `env:"CONFETTI_STATIC_PORT" def:"8080" reload:"static"`
+	LogLevel string `env:"CONFETTI_STATIC_LEVEL" def:"info" reload:"dynamic"`
+}
+
+// TestWatcher_Reload_StaticReport tests if the static fields keep their values and are reported
+// as restart required, while the dynamic fields apply.
+func TestWatcher_Reload_StaticReport(t *testing.T) {
+	dir := t.TempDir()
+	var delivered []Snapshot
+	target := &staticConfigs{}
+	watchOpts := WatchOptions{OnChange: func(snapshot Snapshot) { delivered = append(delivered, snapshot) }}
+
+	watcher, err := NewWatcher(target, LoaderOptions{ConfigDir: dir, Args: []string{}}, watchOpts)
+	if err != nil {
+		t.Errorf("Expected NewWatcher error: nil, got: %+v", err)
+		return
+	}
+
+	writeConfigDirFiles(t, dir, map[string]string{"CONFETTI_STATIC_PORT": "9090", "CONFETTI_STATIC_LEVEL": "debug"})
+	if err := watcher.Reload(); err != nil {
+		t.Errorf("Expected Reload error: nil, got: %+v", err)
+		return
+	}
+	if len(delivered) != 1 {
+		t.Errorf("Expected Snapshots: 1, got: %d", len(delivered))
+		return
+	}
+
+	snapshot := delivered[0]
+	expectedChanges := []FieldChange{{Path: "LogLevel", Old: "info", New: "debug"}}
+	if !reflect.DeepEqual(snapshot.Changes, expectedChanges) {
+		t.Errorf("Expected Changes: %+v, got: %+v", expectedChanges, snapshot.Changes)
+	}
+	expectedRestart := []FieldChange{{Path: "Port", Old: 8080, New: 9090, Static: true}}
+	if !reflect.DeepEqual(snapshot.RestartRequired, expectedRestart) {
+		t.Errorf("Expected RestartRequired: %+v, got: %+v", expectedRestart, snapshot.RestartRequired)
+	}
+
+	current := snapshot.Config.(*staticConfigs)
+	if current.Port != 8080 || current.LogLevel != "debug" {
+		t.Errorf("Expected Port and LogLevel to be: 8080, debug, got: %d, %s", current.Port, current.LogLevel)
+	}
+	if snapshot.Reports[0].Source != SourceDefault {
+		t.Errorf("Expected the report of Port to be kept, got source: %s", snapshot.Reports[0].Source)
+	}
+}
+
+// TestWatcher_Reload_StaticReportOnce tests if the same static changes are delivered only once,
+// as the static fields keep their previous values.
+func TestWatcher_Reload_StaticReportOnce(t *testing.T) {
+	dir := t.TempDir()
+	var delivered []Snapshot
+	watchOpts := WatchOptions{OnChange: func(snapshot Snapshot) { delivered = append(delivered, snapshot) }}
+
+	watcher, err := NewWatcher(&staticConfigs{}, LoaderOptions{ConfigDir: dir, Args: []string{}}, watchOpts)
+	if err != nil {
+		t.Errorf("Expected NewWatcher error: nil, got: %+v", err)
+		return
+	}
+
+	reload := func(files map[string]string, expectedDelivered int) {
+		t.Helper()
+		writeConfigDirFiles(t, dir, files)
+		if err := watcher.Reload(); err != nil {
+			t.Errorf("Expected Reload error: nil, got: %+v", err)
+		}
+		if len(delivered) != expectedDelivered {
+			t.Errorf("Expected Snapshots: %d, got: %d", expectedDelivered, len(delivered))
+		}
+	}
+
+	reload(map[string]string{"CONFETTI_STATIC_PORT": "9090"}, 1)
+	// Nothing changed since the last report.
+	reload(map[string]string{}, 1)
+	// The dynamic changes are delivered along with the pending static ones.
+	reload(map[string]string{"CONFETTI_STATIC_LEVEL": "debug"}, 2)
+	if len(delivered) == 2 && len(delivered[1].RestartRequired) != 1 {
+		t.Errorf("Expected the static change to be delivered again, got: %+v", delivered[1].RestartRequired)
+	}
+	// Another static value is a new report.
+	reload(map[string]string{"CONFETTI_STATIC_PORT": "9091"}, 3)
+	reload(map[string]string{}, 3)
+}
+
+// TestWatcher_Reload_StaticReject tests if a reload that changes static fields is rejected as a whole.
+func TestWatcher_Reload_StaticReject(t *testing.T) {
+	dir := t.TempDir()
+	target := &staticConfigs{}
+	watchOpts := WatchOptions{StaticPolicy: StaticPolicyReject}
+
+	watcher, err := NewWatcher(target, LoaderOptions{ConfigDir: dir, Args: []string{}}, watchOpts)
+	if err != nil {
+		t.Errorf("Expected NewWatcher error: nil, got: %+v", err)
+		return
+	}
+
+	writeConfigDirFiles(t, dir, map[string]string{"CONFETTI_STATIC_PORT": "9090", "CONFETTI_STATIC_LEVEL": "debug"})
+	err = watcher.Reload()
+	if !errors.Is(err, ErrRestartRequired) || !strings.Contains(err.Error(), "Port changed") {
+		t.Errorf("Expected Reload error: %+v, got: %+v", ErrRestartRequired, err)
+	}
+	if watcher.Current().Config != target {
+		t.Errorf("Expected the current Snapshot to stay in effect")
+	}
+
+	// Changing only the dynamic fields is fine.
+	writeConfigDirFiles(t, dir, map[string]string{"CONFETTI_STATIC_PORT": "8080"})
+	if err := watcher.Reload(); err != nil {
+		t.Errorf("Expected Reload error: nil, got: %+v", err)
+	}
+	if watcher.Current().Config.(*staticConfigs).LogLevel != "debug" {
+		t.Errorf("Expected LogLevel to be: debug, got: %+v", watcher.Current().Config)
+	}
+}
+
+// TestNewWatcher_InvalidReloadTag tests if an unknown reload tag or static policy results in an error.
+func TestNewWatcher_InvalidReloadTag(t *testing.T) {
+	target := &struct {
+		Port int `reload:"sometimes"`
+	}{}
+	_, err := NewWatcher(target, LoaderOptions{Args: []string{}}, WatchOptions{})
+	if err == nil || !strings.Contains(err.Error(), `invalid reload tag "sometimes"`) {
+		t.Errorf("Expected NewWatcher error for an invalid reload tag, got: %+v", err)
+	}
+
+	_, err = NewWatcher(&staticConfigs{}, LoaderOptions{Args: []string{}}, WatchOptions{StaticPolicy: "ignore"})
+	if err == nil || !strings.Contains(err.Error(), "unknown static policy: ignore") {
+		t.Errorf("Expected NewWatcher error for an unknown static policy, got: %+v", err)
+	}
+}